@@ -5,8 +5,11 @@ go 1.22.2
 toolchain go1.22.4
 
 require (
+	github.com/fogleman/gg v1.3.0
+	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0
 	github.com/mrmxf/opentsg-modules v0.0.0-20240614100723-886e1b917070
 	github.com/spf13/cobra v1.8.1
+	golang.org/x/image v0.14.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -27,8 +30,6 @@ require (
 	github.com/aws/smithy-go v1.18.1 // indirect
 	github.com/cbroglie/mustache v1.4.0 // indirect
 	github.com/cespare/xxhash v1.1.0 // indirect
-	github.com/fogleman/gg v1.3.0 // indirect
-	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/jmespath/go-jmespath v0.4.0 // indirect
 	github.com/matoous/go-nanoid v1.5.0 // indirect
@@ -38,5 +39,4 @@ require (
 	github.com/xeipuuv/gojsonpointer v0.0.0-20180127040702-4e3ac2762d5f // indirect
 	github.com/xeipuuv/gojsonreference v0.0.0-20180127040603-bd5ef7bd5415 // indirect
 	github.com/xeipuuv/gojsonschema v1.2.0 // indirect
-	golang.org/x/image v0.14.0 // indirect
 )
@@ -0,0 +1,205 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mrmxf/opentsg-modules/opentsg-core/gridgen"
+)
+
+// the packing orders of the carve planner
+const (
+	PackingRow    = "row"
+	PackingColumn = "column"
+)
+
+// CarveConfig is the carve block of a configuration, it describes how the flat
+// canvas is sliced into the output rasters of the LED processors.
+type CarveConfig struct {
+	// Outputs are the output rasters, in the order they are filled.
+	Outputs []CarveOutput `json:"outputs" yaml:"outputs"`
+	// Packing is "row" to fill each output in rows from the top left,
+	// or "column" to fill each output in columns. Defaults to row.
+	Packing string `json:"packing" yaml:"packing"`
+}
+
+// CarveOutput is the resolution of a single output,
+// such as a processor port.
+type CarveOutput struct {
+	Name   string `json:"name" yaml:"name"`
+	Width  int    `json:"width" yaml:"width"`
+	Height int    `json:"height" yaml:"height"`
+}
+
+/*
+planCarve assigns every tile of the model a carve position in one of the outputs.
+
+The tiles are taken in the reading order of the flat canvas, for row packing,
+or column by column for column packing. They are packed in shelves that fill each
+output in turn. A tile is never split across outputs, so the strips of a tile
+are kept together as they are on the flat canvas.
+
+The outputs are laid out side by side, from left to right, in the carve dimensions.
+Only the outputs that have tiles carved to them are added to the TSIG.
+*/
+func planCarve(m *Model, c CarveConfig) error {
+
+	packing := c.Packing
+	if packing == "" {
+		packing = PackingRow
+	}
+
+	if packing != PackingRow && packing != PackingColumn {
+		return fmt.Errorf("unknown carve packing %q, the packing must be %q or %q", c.Packing, PackingRow, PackingColumn)
+	}
+
+	if len(c.Outputs) == 0 {
+		return fmt.Errorf("no carve outputs were given")
+	}
+
+	names := map[string]bool{}
+	for _, o := range c.Outputs {
+		if o.Name == "" || o.Width <= 0 || o.Height <= 0 {
+			return fmt.Errorf("carve output %q must have a name, and a width and height greater than 0", o.Name)
+		}
+
+		if names[o.Name] {
+			return fmt.Errorf("carve output %q has been declared more than once", o.Name)
+		}
+		names[o.Name] = true
+	}
+
+	units := carveUnits(m.Tiles)
+
+	// swap the axes for column packing, so the same
+	// shelf packing is used for both
+	axes := func(x, y int) (int, int) {
+		if packing == PackingColumn {
+			return y, x
+		}
+		return x, y
+	}
+
+	sort.SliceStable(units, func(i, j int) bool {
+		ai, bi := axes(units[i].bounds.X0, units[i].bounds.Y0)
+		aj, bj := axes(units[j].bounds.X0, units[j].bounds.Y0)
+		if bi != bj {
+			return bi < bj
+		}
+		return ai < aj
+	})
+
+	m.Outputs = map[string]gridgen.XY2D{}
+	offset, height := 0, 0
+	output := 0
+	// the position in the current output, and the size of the current shelf
+	along, across, shelf := 0, 0, 0
+
+	for _, u := range units {
+		w, h := u.bounds.X1-u.bounds.X0, u.bounds.Y1-u.bounds.Y0
+		uAlong, uAcross := axes(w, h)
+
+		for {
+			if output >= len(c.Outputs) {
+				return fmt.Errorf("the tiles do not fit in the %v carve outputs, %s could not be carved", len(c.Outputs), u.name)
+			}
+
+			o := c.Outputs[output]
+			oAlong, oAcross := axes(o.Width, o.Height)
+
+			// start a new shelf if the tile is too long for this one
+			if along+uAlong > oAlong && along != 0 {
+				along, across, shelf = 0, across+shelf, 0
+			}
+
+			if along+uAlong <= oAlong && across+uAcross <= oAcross {
+				break
+			}
+
+			if along == 0 && across == 0 {
+				return fmt.Errorf("%s is %vx%v pixels and does not fit in the %vx%v carve output %s", u.name, w, h, o.Width, o.Height, o.Name)
+			}
+
+			// move to the next output
+			m.Outputs[o.Name] = gridgen.XY2D{X0: offset, Y0: 0, X1: offset + o.Width, Y1: o.Height}
+			offset += o.Width
+			height = max(height, o.Height)
+			output++
+			along, across, shelf = 0, 0, 0
+		}
+
+		o := c.Outputs[output]
+		x, y := axes(along, across)
+		for _, t := range u.tiles {
+			m.Tiles[t].Carve = gridgen.XY{Destination: o.Name,
+				X: offset + x + m.Tiles[t].Flat.X - u.bounds.X0,
+				Y: y + m.Tiles[t].Flat.Y - u.bounds.Y0}
+		}
+
+		along += uAlong
+		shelf = max(shelf, uAcross)
+	}
+
+	// the output that was being filled is used as well
+	if len(units) > 0 {
+		o := c.Outputs[output]
+		m.Outputs[o.Name] = gridgen.XY2D{X0: offset, Y0: 0, X1: offset + o.Width, Y1: o.Height}
+		offset += o.Width
+		height = max(height, o.Height)
+	}
+
+	m.Carve = gridgen.XY2D{X0: 0, Y0: 0, X1: offset, Y1: height}
+
+	return nil
+}
+
+// carveUnit is a physical tile, made of one or
+// more tiles of the model if it is split into strips.
+type carveUnit struct {
+	name   string
+	tiles  []int
+	bounds gridgen.XY2D
+}
+
+// carveUnits groups the strips of each physical tile, with the
+// bounds of the tile on the flat canvas.
+func carveUnits(tiles []ModelTile) []carveUnit {
+
+	units := []carveUnit{}
+	index := map[string]int{}
+	for i, t := range tiles {
+		name := physicalName(t)
+
+		u, ok := index[name]
+		if !ok {
+			u = len(units)
+			index[name] = u
+			units = append(units, carveUnit{name: name, bounds: gridgen.XY2D{X0: t.Flat.X, Y0: t.Flat.Y, X1: t.Flat.X + t.Size.X, Y1: t.Flat.Y + t.Size.Y}})
+		}
+
+		b := &units[u].bounds
+		b.X0, b.Y0 = min(b.X0, t.Flat.X), min(b.Y0, t.Flat.Y)
+		b.X1, b.Y1 = max(b.X1, t.Flat.X+t.Size.X), max(b.Y1, t.Flat.Y+t.Size.Y)
+		units[u].tiles = append(units[u].tiles, i)
+	}
+
+	return units
+}
+
+// physicalName returns the name of the physical tile a tile is part of,
+// which is the tile name unless the tile is a strip.
+func physicalName(t ModelTile) string {
+	for _, tag := range t.Tags {
+		if name, ok := strings.CutPrefix(tag, "tile:"); ok {
+			return name
+		}
+	}
+
+	return t.Name
+}
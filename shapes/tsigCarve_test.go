@@ -0,0 +1,81 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"testing"
+
+	"github.com/mrmxf/opentsg-modules/opentsg-core/gridgen"
+)
+
+func TestPlanCarve(t *testing.T) {
+
+	at := func(output string, x, y int) gridgen.XY {
+		return gridgen.XY{Destination: output, X: x, Y: y}
+	}
+
+	for _, tc := range []struct {
+		name   string
+		config CarveConfig
+		// want is the carve of each tile of the 3x2 wall
+		want  map[string]gridgen.XY
+		carve gridgen.XY2D
+		err   bool
+	}{
+		{name: "one output",
+			config: CarveConfig{Outputs: []CarveOutput{{Name: "a", Width: 30, Height: 20}}},
+			want: map[string]gridgen.XY{
+				"flatwall/r1c0": at("a", 0, 0), "flatwall/r1c1": at("a", 10, 0), "flatwall/r1c2": at("a", 20, 0),
+				"flatwall/r0c0": at("a", 0, 10), "flatwall/r0c1": at("a", 10, 10), "flatwall/r0c2": at("a", 20, 10)},
+			carve: gridgen.XY2D{X1: 30, Y1: 20}},
+		{name: "rows across two outputs",
+			config: CarveConfig{Outputs: []CarveOutput{{Name: "a", Width: 20, Height: 20}, {Name: "b", Width: 20, Height: 20}}},
+			want: map[string]gridgen.XY{
+				"flatwall/r1c0": at("a", 0, 0), "flatwall/r1c1": at("a", 10, 0), "flatwall/r1c2": at("a", 0, 10),
+				"flatwall/r0c0": at("a", 10, 10), "flatwall/r0c1": at("b", 20, 0), "flatwall/r0c2": at("b", 30, 0)},
+			carve: gridgen.XY2D{X1: 40, Y1: 20}},
+		{name: "columns across two outputs",
+			config: CarveConfig{Packing: PackingColumn, Outputs: []CarveOutput{{Name: "a", Width: 20, Height: 20}, {Name: "b", Width: 20, Height: 20}}},
+			want: map[string]gridgen.XY{
+				"flatwall/r1c0": at("a", 0, 0), "flatwall/r0c0": at("a", 0, 10), "flatwall/r1c1": at("a", 10, 0),
+				"flatwall/r0c1": at("a", 10, 10), "flatwall/r1c2": at("b", 20, 0), "flatwall/r0c2": at("b", 20, 10)},
+			carve: gridgen.XY2D{X1: 40, Y1: 20}},
+		{name: "too few outputs",
+			config: CarveConfig{Outputs: []CarveOutput{{Name: "a", Width: 20, Height: 20}}},
+			err:    true},
+		{name: "output smaller than a tile",
+			config: CarveConfig{Outputs: []CarveOutput{{Name: "a", Width: 5, Height: 5}}},
+			err:    true},
+		{name: "unknown packing",
+			config: CarveConfig{Packing: "diagonal", Outputs: []CarveOutput{{Name: "a", Width: 30, Height: 20}}},
+			err:    true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := testWall(t, 3, 2)
+
+			err := planCarve(m, tc.config)
+			if tc.err {
+				if err == nil {
+					t.Fatal("got no error, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, tile := range m.Tiles {
+				if tile.Carve != tc.want[tile.Name] {
+					t.Errorf("%s is carved to %v, want %v", tile.Name, tile.Carve, tc.want[tile.Name])
+				}
+			}
+
+			if m.Carve != tc.carve {
+				t.Errorf("the carve is %v, want %v", m.Carve, tc.carve)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed catalog/tiles.yaml
+var bundledCatalog []byte
+
+// TileModel is a tile product in the tile catalog.
+// The lengths are in millimetres and the weight in kilograms,
+// a bezel or weight of 0 is not known.
+type TileModel struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description" yaml:"description"`
+	// Source is where the specifications came from, e.g. the spec sheet
+	Source string `json:"source" yaml:"source"`
+	// the outside size of the tile, including the bezel
+	Width  float64 `json:"width" yaml:"width"`
+	Height float64 `json:"height" yaml:"height"`
+	// the pixel resolution of the tile
+	PixelsX int `json:"pixelsX" yaml:"pixelsX"`
+	PixelsY int `json:"pixelsY" yaml:"pixelsY"`
+	// Bezel is the width of the unlit border around the tile
+	Bezel  float64 `json:"bezel" yaml:"bezel"`
+	Weight float64 `json:"weight" yaml:"weight"`
+}
+
+// tileCatalog is the layout of a catalog file
+type tileCatalog struct {
+	Tiles []TileModel `json:"tiles" yaml:"tiles"`
+}
+
+/*
+loadCatalog returns the bundled tile catalog, with the tiles of the
+user catalog file if one is given. User tiles replace any bundled
+tile of the same name.
+*/
+func loadCatalog(userFile string) ([]TileModel, error) {
+
+	tiles, err := parseCatalog(bundledCatalog, "the bundled catalog")
+	if err != nil {
+		return nil, err
+	}
+
+	if userFile == "" {
+		return tiles, nil
+	}
+
+	userBytes, err := os.ReadFile(userFile)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := parseCatalog(userBytes, userFile)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, u := range user {
+		if i, ok := findTile(tiles, u.Name); ok {
+			tiles[i] = u
+		} else {
+			tiles = append(tiles, u)
+		}
+	}
+
+	return tiles, nil
+}
+
+// parseCatalog reads and checks the tiles of a catalog, which can be yaml or json.
+func parseCatalog(catalogBytes []byte, source string) ([]TileModel, error) {
+
+	var catalog tileCatalog
+	if err := yaml.Unmarshal(catalogBytes, &catalog); err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", source, err)
+	}
+
+	names := map[string]bool{}
+	for _, t := range catalog.Tiles {
+		if t.Name == "" {
+			return nil, fmt.Errorf("a tile in %s has no name", source)
+		}
+
+		if names[t.Name] {
+			return nil, fmt.Errorf("tile %q has been declared more than once in %s", t.Name, source)
+		}
+		names[t.Name] = true
+
+		if t.Width <= 0 || t.Height <= 0 || t.PixelsX <= 0 || t.PixelsY <= 0 {
+			return nil, fmt.Errorf("tile %q in %s must have a width, height, pixelsX and pixelsY greater than 0", t.Name, source)
+		}
+
+		if t.Bezel < 0 || t.Weight < 0 {
+			return nil, fmt.Errorf("tile %q in %s can not have a negative bezel or weight", t.Name, source)
+		}
+	}
+
+	return catalog.Tiles, nil
+}
+
+// findTile returns the index of the named tile in the catalog
+func findTile(tiles []TileModel, name string) (int, bool) {
+	for i, t := range tiles {
+		if t.Name == name {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+/*
+applyCatalog sets the tile dimensions and pixels of a configuration
+from its catalog tile, given as the tile field. Blocks of the configuration,
+such as the surfaces of a stage or the shapes of a scene, can each give their
+own catalog tile.
+
+The dimensions are converted to the units of the configuration, or of the
+block, and the units of the configuration are set to metres if none are given.
+The bezel of the tile is used, if it is known and the configuration does not
+give one.
+*/
+func applyCatalog(conf []byte, tiles []TileModel) ([]byte, error) {
+
+	fields := map[string]any{}
+	if err := yaml.Unmarshal(conf, &fields); err != nil {
+		return nil, err
+	}
+
+	// the fields with a catalog tile, at the top level or in a block
+	_, top := fields["tile"]
+	blocks := []unitBlock{}
+	for _, v := range fields {
+		blocks = tiledBlocks(v, "", blocks)
+	}
+
+	if !top && len(blocks) == 0 {
+		return conf, nil
+	}
+
+	units, _ := fields["units"].(string)
+	if units == "" {
+		units = "m"
+		fields["units"] = units
+	}
+
+	if top {
+		blocks = append(blocks, unitBlock{fields: fields})
+	}
+
+	for _, block := range blocks {
+		// the blocks without their own units have those of the configuration
+		if block.units == "" {
+			block.units = units
+		}
+
+		if err := catalogTile(block, tiles); err != nil {
+			return nil, err
+		}
+	}
+
+	return yaml.Marshal(fields)
+}
+
+// tiledBlocks appends the blocks of the value, and the blocks within them,
+// that give a catalog tile, with the units of the block they are in.
+func tiledBlocks(v any, units string, blocks []unitBlock) []unitBlock {
+	switch b := v.(type) {
+	case map[string]any:
+		if u, ok := b["units"]; ok {
+			units = fmt.Sprint(u)
+		}
+		if _, ok := b["tile"]; ok {
+			blocks = append(blocks, unitBlock{fields: b, units: units})
+		}
+		for _, f := range b {
+			blocks = tiledBlocks(f, units, blocks)
+		}
+	case []any:
+		for _, f := range b {
+			blocks = tiledBlocks(f, units, blocks)
+		}
+	}
+
+	return blocks
+}
+
+// catalogTile sets the fields of the catalog tile of a block, in the units of the block,
+// with the bezel of the tile unless one is given.
+func catalogTile(block unitBlock, tiles []TileModel) error {
+
+	length, ok := unitLengths[block.units]
+	if !ok {
+		return fmt.Errorf("unknown units %q, the units must be mm, cm, m or in", block.units)
+	}
+
+	fields := block.fields
+
+	name := fields["tile"]
+	i, ok := findTile(tiles, fmt.Sprint(name))
+	if !ok {
+		return fmt.Errorf("no tile %q found in the tile catalog, run \"tsig tiles list\" for the available tiles", name)
+	}
+	tile := tiles[i]
+
+	for _, field := range []string{"tileWidth", "tileHeight", "dx", "dy", "pixelPitch"} {
+		if _, ok := fields[field]; ok {
+			return fmt.Errorf("%s and a catalog tile can not both be given", field)
+		}
+	}
+
+	fields["tileWidth"] = tile.Width / length
+	fields["tileHeight"] = tile.Height / length
+	fields["dx"] = tile.PixelsX
+	fields["dy"] = tile.PixelsY
+	if _, ok := fields["bezel"]; !ok && tile.Bezel != 0 {
+		fields["bezel"] = tile.Bezel / length
+	}
+
+	return nil
+}
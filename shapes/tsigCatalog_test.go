@@ -0,0 +1,162 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseCatalog(t *testing.T) {
+
+	for _, tc := range []struct {
+		name    string
+		catalog string
+		tiles   []TileModel
+		err     string
+	}{
+		{name: "yaml",
+			catalog: "tiles:\n  - name: a\n    width: 500\n    height: 250\n    pixelsX: 192\n    pixelsY: 96\n    bezel: 1.5\n    weight: 7\n",
+			tiles:   []TileModel{{Name: "a", Width: 500, Height: 250, PixelsX: 192, PixelsY: 96, Bezel: 1.5, Weight: 7}}},
+		{name: "json",
+			catalog: `{"tiles": [{"name": "a", "source": "spec sheet", "width": 500, "height": 500, "pixelsX": 192, "pixelsY": 192}]}`,
+			tiles:   []TileModel{{Name: "a", Source: "spec sheet", Width: 500, Height: 500, PixelsX: 192, PixelsY: 192}}},
+		{name: "no tiles", catalog: "tiles: []\n", tiles: []TileModel{}},
+		{name: "no name",
+			catalog: "tiles:\n  - width: 500\n    height: 500\n    pixelsX: 192\n    pixelsY: 192\n",
+			err:     "has no name"},
+		{name: "declared twice",
+			catalog: "tiles:\n  - name: a\n    width: 500\n    height: 500\n    pixelsX: 192\n    pixelsY: 192\n  - name: a\n    width: 500\n    height: 500\n    pixelsX: 192\n    pixelsY: 192\n",
+			err:     "declared more than once"},
+		{name: "no pixels",
+			catalog: "tiles:\n  - name: a\n    width: 500\n    height: 500\n",
+			err:     "greater than 0"},
+		{name: "negative bezel",
+			catalog: "tiles:\n  - name: a\n    width: 500\n    height: 500\n    pixelsX: 192\n    pixelsY: 192\n    bezel: -1\n",
+			err:     "negative bezel or weight"},
+		{name: "not a catalog", catalog: "tiles: a tile\n", err: "error reading test"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tiles, err := parseCatalog([]byte(tc.catalog), "test")
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("got the error %v, want an error of %q", err, tc.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(tiles) != len(tc.tiles) || (len(tiles) > 0 && !reflect.DeepEqual(tiles, tc.tiles)) {
+				t.Errorf("got the tiles %+v, want %+v", tiles, tc.tiles)
+			}
+		})
+	}
+}
+
+func TestLoadCatalog(t *testing.T) {
+
+	bundled, err := parseCatalog(bundledCatalog, "the bundled catalog")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// replace the first bundled tile and add another
+	user := filepath.Join(t.TempDir(), "tiles.yaml")
+	conf := "tiles:\n  - name: " + bundled[0].Name + "\n    width: 1\n    height: 1\n    pixelsX: 1\n    pixelsY: 1\n" +
+		"  - name: user-tile\n    width: 2\n    height: 2\n    pixelsX: 2\n    pixelsY: 2\n"
+	if err := os.WriteFile(user, []byte(conf), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tiles, err := loadCatalog(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tiles) != len(bundled)+1 || tiles[0].Width != 1 || tiles[len(tiles)-1].Name != "user-tile" {
+		t.Errorf("got the tiles %+v, want the bundled tiles %+v with the first replaced and user-tile added", tiles, bundled)
+	}
+
+	if _, err := loadCatalog(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("a missing user catalog was loaded")
+	}
+}
+
+func TestApplyCatalog(t *testing.T) {
+
+	tiles := []TileModel{
+		{Name: "square", Width: 500, Height: 500, PixelsX: 192, PixelsY: 192, Bezel: 2},
+		{Name: "unknown-bezel", Width: 600, Height: 300, PixelsX: 256, PixelsY: 128},
+	}
+
+	for _, tc := range []struct {
+		name string
+		conf string
+		// the fields that are set, by their path, whole
+		// numbers are read back from the yaml as ints
+		fields map[string]any
+		err    string
+	}{
+		{name: "in metres without units",
+			conf:   "shape: flatwall\ntile: square\n",
+			fields: map[string]any{"units": "m", "tileWidth": 0.5, "tileHeight": 0.5, "dx": 192, "dy": 192, "bezel": 0.002}},
+		{name: "in the units of the config",
+			conf:   "shape: flatwall\nunits: mm\ntile: unknown-bezel\n",
+			fields: map[string]any{"units": "mm", "tileWidth": 600, "tileHeight": 300, "dx": 256, "dy": 128, "bezel": nil}},
+		{name: "the bezel of the config is kept",
+			conf:   "shape: flatwall\nunits: mm\ntile: square\nbezel: 5\n",
+			fields: map[string]any{"tileWidth": 500, "bezel": 5}},
+		{name: "blocks in their own units",
+			conf: "shape: stage\nunits: m\nwall:\n  units: cm\n  tile: square\nfloor:\n  tile: unknown-bezel\n",
+			fields: map[string]any{"units": "m", "wall.tileWidth": 50, "wall.bezel": 0.2, "wall.dx": 192,
+				"floor.tileWidth": 0.6, "floor.tileHeight": 0.3, "floor.dy": 128}},
+		{name: "blocks of a scene",
+			conf:   "shape: scene\nshapes:\n  - name: a\n    config:\n      tile: square\n",
+			fields: map[string]any{"units": "m", "shapes.0.config.tileWidth": 0.5, "shapes.0.config.dx": 192}},
+		{name: "no catalog tile",
+			conf:   "shape: flatwall\ntileWidth: 1\n",
+			fields: map[string]any{"units": nil, "tileWidth": 1}},
+		{name: "not in the catalog", conf: "shape: flatwall\ntile: round\n",
+			err: `no tile "round"`},
+		{name: "tile and pixels", conf: "shape: flatwall\ntile: square\ndx: 10\n",
+			err: "dx and a catalog tile can not both be given"},
+		{name: "unknown units", conf: "shape: flatwall\nunits: furlongs\ntile: square\n",
+			err: `unknown units "furlongs"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			conf, err := applyCatalog([]byte(tc.conf), tiles)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("got the error %v, want an error of %q", err, tc.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fields := map[string]any{}
+			if err := yaml.Unmarshal(conf, &fields); err != nil {
+				t.Fatal(err)
+			}
+
+			for path, want := range tc.fields {
+				if got := configValue(fields, path); got != want {
+					t.Errorf("%s is %v, want %v", path, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/truetype"
+	"github.com/mrmxf/opentsg-modules/opentsg-core/gridgen"
+	"github.com/spf13/cobra"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+)
+
+// add the command and its flags to the main handler
+func init() {
+	cmdChecker.Flags().StringVar(&tsigFile, "tsig", "", "The TSIG file to draw the flat canvas of")
+	cmdChecker.Flags().StringVar(&outFile, "outputFile", "./output", "The name of the output png")
+	cmdChecker.Flags().StringVar(&checkerLabel, "label", LabelName, "Label the tiles with their name or index")
+	cmdChecker.Flags().Float64Var(&checkerScale, "scale", 1, "The scale of the png to the flat canvas")
+
+	cmdBoth.AddCommand(cmdChecker)
+}
+
+// checker flags
+var (
+	checkerLabel = LabelName
+	checkerScale = 1.0
+)
+
+var cmdChecker = &cobra.Command{
+	Use:   "checker",
+	Short: "draw a png of the tiles of a TSIG",
+	Long: `
+	Draw a png of the flat canvas of a TSIG, with each tile filled in
+	its own colour, outlined and labelled with its name or index.
+	Use it as the texture of the obj to see flipped or misplaced tiles.
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		tsigBytes, err := os.ReadFile(tsigFile)
+		if err != nil {
+			return err
+		}
+
+		var tpig gridgen.TPIG
+		err = json.Unmarshal(tsigBytes, &tpig)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %v", tsigFile, err)
+		}
+
+		img, err := drawChecker(tpig, checkerLabel, checkerScale)
+		if err != nil {
+			return err
+		}
+
+		fPNG, err := os.Create(outFile + ".png")
+		if err != nil {
+			return err
+		}
+		defer fPNG.Close()
+
+		return png.Encode(fPNG, img)
+	},
+}
+
+const (
+	// LabelName labels each tile with its TSIG name
+	LabelName = "name"
+	// LabelIndex labels each tile with its index in the TSIG
+	LabelIndex = "index"
+)
+
+/*
+drawChecker draws the flat canvas of a TSIG, with each tile filled in its own
+colour, outlined and labelled. The canvas is scaled by scale, so large
+canvases can be drawn smaller.
+
+Tiles are labelled with their name, without the shape as every tile has the
+same shape, or their index in the TSIG counting from 0. The labels read the
+right way up on the flat canvas, so a tile that is flipped or turned on the
+obj shows a flipped or turned label.
+*/
+func drawChecker(tpig gridgen.TPIG, label string, scale float64) (image.Image, error) {
+
+	switch label {
+	case LabelName, LabelIndex:
+	default:
+		return nil, fmt.Errorf("unknown label %q, the label must be %q or %q", label, LabelName, LabelIndex)
+	}
+
+	if scale <= 0 {
+		return nil, fmt.Errorf("the scale must be greater than 0, got %v", scale)
+	}
+
+	flat := tpig.Dimensions.Flat
+	width := int(math.Round(float64(flat.X1-flat.X0) * scale))
+	height := int(math.Round(float64(flat.Y1-flat.Y0) * scale))
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("the flat canvas of %vx%v pixels is empty at a scale of %v", flat.X1-flat.X0, flat.Y1-flat.Y0, scale)
+	}
+
+	ttf, err := truetype.Parse(gobold.TTF)
+	if err != nil {
+		return nil, err
+	}
+
+	dc := gg.NewContext(width, height)
+	dc.SetRGB(0.1, 0.1, 0.1)
+	dc.Clear()
+
+	// the font faces of each label size, as they are slow to make
+	faces := map[int]font.Face{}
+	for i, t := range tpig.Tilelayout {
+		x := float64(t.Layout.Flat.X-flat.X0) * scale
+		y := float64(t.Layout.Flat.Y-flat.Y0) * scale
+		w, h := float64(t.Layout.Size.X)*scale, float64(t.Layout.Size.Y)*scale
+
+		// step the hue by the golden ratio, so neighbouring tiles differ
+		r, g, b := hsv(math.Mod(float64(i)*0.618033988749895, 1), 0.55, 0.9)
+		dc.SetRGB(r, g, b)
+		dc.DrawRectangle(x, y, w, h)
+		dc.Fill()
+
+		outline := math.Max(1, math.Min(w, h)/50)
+		dc.SetRGB(0, 0, 0)
+		dc.SetLineWidth(outline)
+		dc.DrawRectangle(x+outline/2, y+outline/2, w-outline, h-outline)
+		dc.Stroke()
+
+		text := fmt.Sprint(i)
+		if label == LabelName {
+			// the shape is the same for every tile
+			_, text, _ = strings.Cut(t.Name, "/")
+			if text == "" {
+				text = t.Name
+			}
+		}
+
+		// fit the label to 80% of the tile
+		size := int(math.Min(h*0.4, w*0.8/(0.62*float64(len(text)))))
+		if size < 4 {
+			continue
+		}
+
+		face, ok := faces[size]
+		if !ok {
+			face = truetype.NewFace(ttf, &truetype.Options{Size: float64(size)})
+			faces[size] = face
+		}
+		dc.SetFontFace(face)
+
+		// dark labels on light tiles
+		if 0.299*r+0.587*g+0.114*b > 0.5 {
+			dc.SetRGB(0, 0, 0)
+		} else {
+			dc.SetRGB(1, 1, 1)
+		}
+		dc.DrawStringAnchored(text, x+w/2, y+h/2, 0.5, 0.35)
+	}
+
+	return dc.Image(), nil
+}
+
+// hsv returns the red, green and blue of a hue, saturation and value between 0 and 1
+func hsv(h, s, v float64) (float64, float64, float64) {
+
+	i := math.Floor(h * 6)
+	f := h*6 - i
+	p, q, t := v*(1-s), v*(1-f*s), v*(1-(1-f)*s)
+
+	switch int(i) % 6 {
+	case 0:
+		return v, t, p
+	case 1:
+		return q, v, p
+	case 2:
+		return p, v, t
+	case 3:
+		return p, q, v
+	case 4:
+		return t, p, v
+	default:
+		return v, p, q
+	}
+}
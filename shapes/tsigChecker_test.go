@@ -0,0 +1,95 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/mrmxf/opentsg-modules/opentsg-core/gridgen"
+)
+
+func TestDrawChecker(t *testing.T) {
+
+	// a 3x2 wall of 100 pixel tiles, without its r1c1 tile
+	m, err := FlatWall{TileWidth: 1, TileHeight: 1, WallWidth: 3, WallHeight: 2, Dx: 100, Dy: 100}.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Tiles = append(m.Tiles[:4], m.Tiles[5:]...)
+
+	var tsig bytes.Buffer
+	if err := writeTSIG(&tsig, m); err != nil {
+		t.Fatal(err)
+	}
+
+	var tpig gridgen.TPIG
+	if err := json.Unmarshal(tsig.Bytes(), &tpig); err != nil {
+		t.Fatal(err)
+	}
+
+	// the background is a grey of 0.1
+	background := color.RGBA{R: 25, G: 25, B: 25, A: 255}
+
+	for _, tc := range []struct {
+		name  string
+		label string
+		scale float64
+		// the size of the png
+		width, height int
+		err           string
+	}{
+		{name: "names", label: LabelName, scale: 1, width: 300, height: 200},
+		{name: "indices", label: LabelIndex, scale: 1, width: 300, height: 200},
+		{name: "half scale", label: LabelName, scale: 0.5, width: 150, height: 100},
+		{name: "too small for labels", label: LabelName, scale: 0.05, width: 15, height: 10},
+		{name: "unknown label", label: "colour", scale: 1, err: `unknown label "colour"`},
+		{name: "no scale", label: LabelName, scale: 0, err: "the scale must be greater than 0"},
+		{name: "empty canvas", label: LabelName, scale: 0.001, err: "is empty at a scale of 0.001"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			img, err := drawChecker(tpig, tc.label, tc.scale)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("got the error %v, want an error of %q", err, tc.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if b := img.Bounds(); b.Dx() != tc.width || b.Dy() != tc.height {
+				t.Fatalf("got a %vx%v png, want %vx%v", b.Dx(), b.Dy(), tc.width, tc.height)
+			}
+
+			// sample inside the outline of each tile, away from the label
+			fills := map[color.Color]string{}
+			for _, tile := range tpig.Tilelayout {
+				x := int(float64(tile.Layout.Flat.X)*tc.scale + float64(tile.Layout.Size.X)*tc.scale/4)
+				y := int(float64(tile.Layout.Flat.Y)*tc.scale + float64(tile.Layout.Size.Y)*tc.scale/4)
+				fill := color.RGBAModel.Convert(img.At(x, y))
+				if fill == background {
+					t.Errorf("%s is not filled at %v,%v", tile.Name, x, y)
+				}
+				if other, ok := fills[fill]; ok {
+					t.Errorf("%s and %s are both filled with %v", tile.Name, other, fill)
+				}
+				fills[fill] = tile.Name
+			}
+
+			// the missing tile is not drawn
+			missing := color.RGBAModel.Convert(img.At(int(150*tc.scale), int(25*tc.scale)))
+			if missing != background {
+				t.Errorf("the missing tile is drawn in %v, want the background %v", missing, background)
+			}
+		})
+	}
+}
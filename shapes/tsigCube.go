@@ -10,7 +10,6 @@ information aobut the rest of it
 package shapes
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -46,8 +45,13 @@ func (c Cube) ObjType() string {
 	return "cube"
 }
 
+// Generate generates a TSIG and OBJ for a cube with no front panel.
+func (c Cube) Generate(wObj, wTsig io.Writer) error {
+	return generate(c, wObj, wTsig)
+}
+
 /*
-GenHalfCubeOBJ generates a TSIG and OBJ for a cube with no front panel.
+Build builds the model of a cube with no front panel.
 The dimensions are as so:
 
   - Width is the x plane
@@ -58,13 +62,13 @@ The dimensions are as so:
 
     Errors will be returned if the tiles do not fit exactly into the dimensions. E.g. a tile width of 1 is given and the cube has a width of 3.5
 */
-func (c Cube) Generate(wObj, wTsig io.Writer) error {
+func (c Cube) Build() (*Model, error) {
 
 	// check the dimensions
 	err := halfCubeFence(c.TileHeight, c.TileWidth, c.CubeWidth, c.CubeHeight, c.CubeDepth)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// get the dimensions of the flat display.
@@ -76,7 +80,7 @@ func (c Cube) Generate(wObj, wTsig io.Writer) error {
 	topbot := int((c.CubeDepth * 2 / c.TileWidth) * (c.CubeWidth / c.TileHeight))
 	back := int((c.CubeHeight / c.TileHeight) * (c.CubeWidth / c.TileWidth))
 
-	tiles := make([]gridgen.Tilelayout, leftRight+topbot+back)
+	tiles := make([]ModelTile, leftRight+topbot+back)
 
 	// calculate the uv map steps in each direction
 	uStep := c.TileWidth / (c.CubeWidth + c.CubeDepth*2)
@@ -98,32 +102,32 @@ func (c Cube) Generate(wObj, wTsig io.Writer) error {
 		plane string
 		// is it facing the expected direction
 		inverse bool
+		// the name of the face
+		face string
 	}
 
 	// set all the planes of the cube
 	planes := []plane{
 		// left wall
 		{iEnd: c.CubeDepth, jEnd: c.CubeHeight, iStep: c.TileWidth, jStep: c.TileHeight, planeConst: 0,
-			plane: "y", vStart: (c.CubeDepth / c.TileHeight) * vStep, inverse: true, uStart: ((c.CubeDepth + c.CubeWidth) / c.TileWidth) * uStep},
+			face: "left", plane: "y", vStart: (c.CubeDepth / c.TileHeight) * vStep, inverse: true, uStart: ((c.CubeDepth + c.CubeWidth) / c.TileWidth) * uStep},
 		// right wall
 		{iEnd: c.CubeDepth, jEnd: c.CubeHeight, iStep: c.TileWidth, jStep: c.TileHeight,
-			planeConst: c.CubeWidth, plane: "y", vStart: (c.CubeDepth / c.TileHeight) * vStep},
+			planeConst: c.CubeWidth, face: "right", plane: "y", vStart: (c.CubeDepth / c.TileHeight) * vStep},
 
 		// back wall
 		{iEnd: c.CubeWidth, jEnd: c.CubeHeight, iStep: c.TileWidth, jStep: c.TileHeight,
-			planeConst: c.CubeDepth, plane: "x", vStart: (c.CubeDepth / c.TileHeight) * vStep, uStart: ((c.CubeDepth) / c.TileWidth) * uStep},
+			planeConst: c.CubeDepth, face: "back", plane: "x", vStart: (c.CubeDepth / c.TileHeight) * vStep, uStart: ((c.CubeDepth) / c.TileWidth) * uStep},
 
 		// Top
 		{iEnd: c.CubeDepth, inverse: true, jEnd: c.CubeWidth, iStep: c.TileWidth, jStep: c.TileHeight,
-			planeConst: c.CubeHeight, plane: "z", vStart: ((c.CubeDepth + c.CubeHeight) / c.TileHeight) * vStep, uStart: ((c.CubeDepth) / c.TileWidth) * uStep},
+			planeConst: c.CubeHeight, face: "top", plane: "z", vStart: ((c.CubeDepth + c.CubeHeight) / c.TileHeight) * vStep, uStart: ((c.CubeDepth) / c.TileWidth) * uStep},
 
 		// Bottom
 		{iEnd: c.CubeDepth, jEnd: c.CubeWidth, iStep: c.TileWidth, jStep: c.TileHeight,
-			planeConst: 0, plane: "z", uStart: ((c.CubeDepth) / c.TileWidth) * uStep},
+			planeConst: 0, face: "bottom", plane: "z", uStart: ((c.CubeDepth) / c.TileWidth) * uStep},
 	}
 
-	// vertexCount the vertexes per face
-	vertexCount := 1
 	tileCount := 0
 
 	for _, p := range planes {
@@ -136,79 +140,52 @@ func (c Cube) Generate(wObj, wTsig io.Writer) error {
 		ujwidth := ujTotal * uStep
 
 		iCount := 0
-		// the obj buffer
-		tileFace := ""
 
 		for i := p.iStart; i < p.iEnd; i += p.iStep {
 
 			jCount := 0
 			for j := p.jStart; j < p.jEnd; j += p.jStep {
+				var corners [4][3]float64
+				var uvs [4][2]float64
 
 				switch p.plane {
 				case "x":
 
-					// do vertex coordinates
-					tileFace += fmt.Sprintf("v %v %v %v \n", p.planeConst, i, j)
-					tileFace += fmt.Sprintf("v %v %v %v \n", p.planeConst, i+p.iStep, j)
-					tileFace += fmt.Sprintf("v %v %v %v \n", p.planeConst, i+p.iStep, j+p.jStep)
-					tileFace += fmt.Sprintf("v %v %v %v \n", p.planeConst, i, j+p.jStep)
+					corners = [4][3]float64{{p.planeConst, i, j}, {p.planeConst, i + p.iStep, j}, {p.planeConst, i + p.iStep, j + p.jStep}, {p.planeConst, i, j + p.jStep}}
 
-					// do texture coordinates
-					tileFace += fmt.Sprintf("vt %v %v \n", p.uStart+width-float64(iCount)*uStep, p.vStart+float64(jCount)*vStep)
-					tileFace += fmt.Sprintf("vt %v %v \n", p.uStart+width-float64(iCount+1)*uStep, p.vStart+float64(jCount)*vStep)
-					tileFace += fmt.Sprintf("vt %v %v \n", p.uStart+width-float64(iCount+1)*uStep, p.vStart+float64(jCount+1)*vStep)
-					tileFace += fmt.Sprintf("vt %v %v \n", p.uStart+width-float64(iCount)*uStep, p.vStart+float64(jCount+1)*vStep)
+					uvs = [4][2]float64{{p.uStart + width - float64(iCount)*uStep, p.vStart + float64(jCount)*vStep}, {p.uStart + width - float64(iCount+1)*uStep, p.vStart + float64(jCount)*vStep}, {p.uStart + width - float64(iCount+1)*uStep, p.vStart + float64(jCount+1)*vStep}, {p.uStart + width - float64(iCount)*uStep, p.vStart + float64(jCount+1)*vStep}}
 
-					tiles[tileCount] = gridgen.Tilelayout{Layout: gridgen.Positions{Flat: gridgen.XY{X: int(math.Round((p.uStart + width - float64(iCount+1)*uStep) * pixelWidth)), Y: int(math.Round((1 - (p.vStart + float64(jCount+1)*vStep)) * pixelHeight))}, Size: gridgen.XY{X: int(c.Dx), Y: int(c.Dy)}}}
+					tiles[tileCount] = ModelTile{Flat: gridgen.XY{X: int(math.Round((p.uStart + width - float64(iCount+1)*uStep) * pixelWidth)), Y: int(math.Round((1 - (p.vStart + float64(jCount+1)*vStep)) * pixelHeight))}, Size: gridgen.XY{X: int(c.Dx), Y: int(c.Dy)}}
 
 				case "y":
 
-					// do vertex coordinates
-					tileFace += fmt.Sprintf("v %v %v %v \n", i, p.planeConst, j)
-					tileFace += fmt.Sprintf("v %v %v %v \n", i+p.iStep, p.planeConst, j)
-					tileFace += fmt.Sprintf("v %v %v %v \n", i+p.iStep, p.planeConst, j+p.jStep)
-					tileFace += fmt.Sprintf("v %v %v %v \n", i, p.planeConst, j+p.jStep)
+					corners = [4][3]float64{{i, p.planeConst, j}, {i + p.iStep, p.planeConst, j}, {i + p.iStep, p.planeConst, j + p.jStep}, {i, p.planeConst, j + p.jStep}}
 
 					// if inversed change the direction of the uv map
 					if p.inverse {
-						tileFace += fmt.Sprintf("vt %v %v \n", p.uStart+width-float64(iCount)*uStep, p.vStart+float64(jCount)*vStep)
-						tileFace += fmt.Sprintf("vt %v %v \n", p.uStart+width-float64(iCount+1)*uStep, p.vStart+float64(jCount)*vStep)
-						tileFace += fmt.Sprintf("vt %v %v \n", p.uStart+width-float64(iCount+1)*uStep, p.vStart+float64(jCount+1)*vStep)
-						tileFace += fmt.Sprintf("vt %v %v \n", p.uStart+width-float64(iCount)*uStep, p.vStart+float64(jCount+1)*vStep)
-						tiles[tileCount] = gridgen.Tilelayout{Layout: gridgen.Positions{Flat: gridgen.XY{X: int(math.Round((p.uStart + width - float64(iCount+1)*uStep) * pixelWidth)), Y: int(math.Round((1 - (p.vStart + float64(jCount+1)*vStep)) * pixelHeight))}, Size: gridgen.XY{X: int(c.Dx), Y: int(c.Dy)}}}
+						uvs = [4][2]float64{{p.uStart + width - float64(iCount)*uStep, p.vStart + float64(jCount)*vStep}, {p.uStart + width - float64(iCount+1)*uStep, p.vStart + float64(jCount)*vStep}, {p.uStart + width - float64(iCount+1)*uStep, p.vStart + float64(jCount+1)*vStep}, {p.uStart + width - float64(iCount)*uStep, p.vStart + float64(jCount+1)*vStep}}
+						tiles[tileCount] = ModelTile{Flat: gridgen.XY{X: int(math.Round((p.uStart + width - float64(iCount+1)*uStep) * pixelWidth)), Y: int(math.Round((1 - (p.vStart + float64(jCount+1)*vStep)) * pixelHeight))}, Size: gridgen.XY{X: int(c.Dx), Y: int(c.Dy)}}
 
 					} else {
 
-						tileFace += fmt.Sprintf("vt %v %v \n", p.uStart+float64(iCount)*uStep, p.vStart+float64(jCount)*vStep)
-						tileFace += fmt.Sprintf("vt %v %v \n", p.uStart+float64(iCount+1)*uStep, p.vStart+float64(jCount)*vStep)
-						tileFace += fmt.Sprintf("vt %v %v \n", p.uStart+float64(iCount+1)*uStep, p.vStart+float64(jCount+1)*vStep)
-						tileFace += fmt.Sprintf("vt %v %v \n", p.uStart+float64(iCount)*uStep, p.vStart+float64(jCount+1)*vStep)
-						tiles[tileCount] = gridgen.Tilelayout{Layout: gridgen.Positions{Flat: gridgen.XY{X: int(math.Round((p.uStart + width - float64(iCount+1)*uStep) * pixelWidth)), Y: int(math.Round((1 - (p.vStart + float64(jCount+1)*vStep)) * pixelHeight))}, Size: gridgen.XY{X: int(c.Dx), Y: int(c.Dy)}}}
+						uvs = [4][2]float64{{p.uStart + float64(iCount)*uStep, p.vStart + float64(jCount)*vStep}, {p.uStart + float64(iCount+1)*uStep, p.vStart + float64(jCount)*vStep}, {p.uStart + float64(iCount+1)*uStep, p.vStart + float64(jCount+1)*vStep}, {p.uStart + float64(iCount)*uStep, p.vStart + float64(jCount+1)*vStep}}
+						tiles[tileCount] = ModelTile{Flat: gridgen.XY{X: int(math.Round((p.uStart + float64(iCount)*uStep) * pixelWidth)), Y: int(math.Round((1 - (p.vStart + float64(jCount+1)*vStep)) * pixelHeight))}, Size: gridgen.XY{X: int(c.Dx), Y: int(c.Dy)}}
 
 					}
 
 				case "z":
-					tileFace += fmt.Sprintf("v %v %v %v \n", i, j+p.jStep, p.planeConst)
-					tileFace += fmt.Sprintf("v %v %v %v \n", i, j, p.planeConst)
-					tileFace += fmt.Sprintf("v %v %v %v \n", i+p.iStep, j, p.planeConst)
-					tileFace += fmt.Sprintf("v %v %v %v \n", i+p.iStep, j+p.jStep, p.planeConst)
+					corners = [4][3]float64{{i, j + p.jStep, p.planeConst}, {i, j, p.planeConst}, {i + p.iStep, j, p.planeConst}, {i + p.iStep, j + p.jStep, p.planeConst}}
 
 					if p.inverse {
 						// write the uv map from the top down instead of the bottom up
-						tileFace += fmt.Sprintf("vt %v %v \n", p.uStart+ujwidth-float64(jCount+1)*uStep, p.vStart+(uTotal-float64(iCount))*vStep)
-						tileFace += fmt.Sprintf("vt %v %v \n", p.uStart+ujwidth-float64(jCount)*uStep, p.vStart+(uTotal-float64(iCount))*vStep)
-						tileFace += fmt.Sprintf("vt %v %v \n", p.uStart+ujwidth-float64(jCount)*uStep, p.vStart+(uTotal-float64(iCount+1))*vStep)
-						tileFace += fmt.Sprintf("vt %v %v \n", p.uStart+ujwidth-float64(jCount+1)*uStep, p.vStart+(uTotal-float64(iCount+1))*vStep)
+						uvs = [4][2]float64{{p.uStart + ujwidth - float64(jCount+1)*uStep, p.vStart + (uTotal-float64(iCount))*vStep}, {p.uStart + ujwidth - float64(jCount)*uStep, p.vStart + (uTotal-float64(iCount))*vStep}, {p.uStart + ujwidth - float64(jCount)*uStep, p.vStart + (uTotal-float64(iCount+1))*vStep}, {p.uStart + ujwidth - float64(jCount+1)*uStep, p.vStart + (uTotal-float64(iCount+1))*vStep}}
 
-						tiles[tileCount] = gridgen.Tilelayout{Layout: gridgen.Positions{Flat: gridgen.XY{X: int(math.Round((p.uStart + ujwidth - float64(jCount+1)*uStep) * pixelWidth)), Y: int(math.Round((1 - (p.vStart + (uTotal-float64(iCount))*vStep)) * pixelHeight))}, Size: gridgen.XY{X: int(c.Dx), Y: int(c.Dy)}}}
+						tiles[tileCount] = ModelTile{Flat: gridgen.XY{X: int(math.Round((p.uStart + ujwidth - float64(jCount+1)*uStep) * pixelWidth)), Y: int(math.Round((1 - (p.vStart + (uTotal-float64(iCount))*vStep)) * pixelHeight))}, Size: gridgen.XY{X: int(c.Dx), Y: int(c.Dy)}}
 
 					} else {
-						tileFace += fmt.Sprintf("vt %v %v \n", p.uStart+ujwidth-float64(jCount+1)*uStep, p.vStart+float64(iCount)*vStep)
-						tileFace += fmt.Sprintf("vt %v %v \n", p.uStart+ujwidth-float64(jCount)*uStep, p.vStart+float64(iCount)*vStep)
-						tileFace += fmt.Sprintf("vt %v %v \n", p.uStart+ujwidth-float64(jCount)*uStep, p.vStart+float64(iCount+1)*vStep)
-						tileFace += fmt.Sprintf("vt %v %v \n", p.uStart+ujwidth-float64(jCount+1)*uStep, p.vStart+float64(iCount+1)*vStep)
+						uvs = [4][2]float64{{p.uStart + ujwidth - float64(jCount+1)*uStep, p.vStart + float64(iCount)*vStep}, {p.uStart + ujwidth - float64(jCount)*uStep, p.vStart + float64(iCount)*vStep}, {p.uStart + ujwidth - float64(jCount)*uStep, p.vStart + float64(iCount+1)*vStep}, {p.uStart + ujwidth - float64(jCount+1)*uStep, p.vStart + float64(iCount+1)*vStep}}
 
-						tiles[tileCount] = gridgen.Tilelayout{Layout: gridgen.Positions{Flat: gridgen.XY{X: int(math.Round((p.uStart + ujwidth - float64(jCount+1)*uStep) * pixelWidth)), Y: int(math.Round((1 - (p.vStart + float64(iCount+1)*vStep)) * pixelHeight))}, Size: gridgen.XY{X: int(c.Dx), Y: int(c.Dy)}}}
+						tiles[tileCount] = ModelTile{Flat: gridgen.XY{X: int(math.Round((p.uStart + ujwidth - float64(jCount+1)*uStep) * pixelWidth)), Y: int(math.Round((1 - (p.vStart + float64(iCount+1)*vStep)) * pixelHeight))}, Size: gridgen.XY{X: int(c.Dx), Y: int(c.Dy)}}
 					}
 
 				default:
@@ -216,28 +193,21 @@ func (c Cube) Generate(wObj, wTsig io.Writer) error {
 					continue
 				}
 
-				// write the face after each tile
-				tileFace += fmt.Sprintf("f %v/%v %v/%v %v/%v %v/%v\n", vertexCount, vertexCount, vertexCount+1, vertexCount+1, vertexCount+2, vertexCount+2, vertexCount+3, vertexCount+3)
-				vertexCount += 4
+				tiles[tileCount].Face, tiles[tileCount].Corners, tiles[tileCount].UVs = p.face, corners, uvs
+
 				jCount++
 				tileCount++
 			}
 			iCount++
 		}
-
-		_, err := wObj.Write([]byte(tileFace))
-		if err != nil {
-			return fmt.Errorf("error writing to obj %v", err)
-		}
 	}
 
-	tsig := gridgen.TPIG{Tilelayout: tiles, Dimensions: gridgen.Dimensions{Flat: gridgen.XY2D{X0: 0, X1: int(pixelWidth), Y0: 0, Y1: int(pixelHeight)}}}
-
-	enc := json.NewEncoder(wTsig)
-	enc.SetIndent("", "    ")
+	gridFromFlat(tiles)
 
-	return enc.Encode(tsig)
+	m := &Model{Shape: c.ObjType(), Tiles: tiles, Flat: gridgen.XY2D{X0: 0, X1: int(pixelWidth), Y0: 0, Y1: int(pixelHeight)}}
+	describeTiles(m)
 
+	return m, nil
 }
 
 func halfCubeFence(tileHeight, tileWidth float64, CubeWidth, CubeHeight, CubeDepth float64) error {
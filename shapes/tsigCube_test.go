@@ -0,0 +1,35 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"testing"
+)
+
+func TestCubeLayout(t *testing.T) {
+
+	for _, tc := range []struct {
+		name string
+		cube Cube
+	}{
+		{name: "example cube", cube: Cube{TileHeight: 0.5, TileWidth: 0.5, CubeWidth: 5, CubeHeight: 5, CubeDepth: 2.5, Dx: 500, Dy: 500}},
+		{name: "cube of single tiles", cube: Cube{TileHeight: 1, TileWidth: 1, CubeWidth: 1, CubeHeight: 1, CubeDepth: 1, Dx: 10, Dy: 10}},
+		{name: "box", cube: Cube{TileHeight: 1, TileWidth: 1, CubeWidth: 3, CubeHeight: 2, CubeDepth: 4, Dx: 20, Dy: 20}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := tc.cube.Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// every face has its flat layout under its uv map
+			if v := validateModel(m, 1); v.problems() > 0 {
+				t.Errorf("got %v mismatches, %v overlaps and %v outside, want none: %v %v %v",
+					len(v.Mismatches), len(v.Overlaps), len(v.Outside), v.Mismatches, v.Overlaps, v.Outside)
+			}
+		})
+	}
+}
@@ -6,7 +6,6 @@
 package shapes
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -29,6 +28,17 @@ type Curve struct {
 	// max angle in radians, is the max angle in both directions from the origin,
 	// so the angle of the curve will be double this value.
 	AzimuthMaxAngle float64 `json:"azimuthMaxAngle" yaml:"azimuthMaxAngle"`
+	// ClosedRing generates a full 360 degree cylinder, the AzimuthMaxAngle
+	// is ignored and the azimuth increment is snapped so an integer number of tiles
+	// closes the circle.
+	ClosedRing bool `json:"closedRing" yaml:"closedRing"`
+	// SeamAngle is the azimuth angle in radians of the uv map seam
+	// of a closed ring. It is ignored for open curves.
+	SeamAngle float64 `json:"seamAngle" yaml:"seamAngle"`
+	// Orientation is "concave" for walls viewed from the inside, or "convex"
+	// for walls viewed from the outside. The face winding and uv map are
+	// set so the TSIG reads left to right from the viewer's side.
+	Orientation string `json:"orientation" yaml:"orientation"`
 	// pixel count properties
 	Dx float64 `json:"dx" yaml:"dx"`
 	Dy float64 `json:"dy" yaml:"dy"`
@@ -40,28 +50,82 @@ func (c Curve) ObjType() string {
 	return "curve"
 }
 
+// Generate generates a TSIG and OBJ for a curved cylindrical wall.
+func (c Curve) Generate(wObj, wTsig io.Writer) error {
+	return generate(c, wObj, wTsig)
+}
+
 /*
-GenCurveOBJ generates a TSIG and OBJ for a curved cylindrical wall.
+Build builds the model of a curved cylindrical wall.
 The wall is centred around 0,0,0
 
+If the curve is a closed ring, then the tiles are laid out anticlockwise
+from the seam angle. Each tile keeps its width, centred in an equal share of the
+circle, so any gap left from the tiles not fitting the circumference is spread
+between the joints and reported in the notes of the model.
+
+The faces point away from the origin, with the uv map read from the inside, unless
+an orientation is given.
+
 Angles are in Radians
 */
-func (c Curve) Generate(wObj, wTsig io.Writer) error {
+func (c Curve) Build() (*Model, error) {
+
+	if err := orientationFence(c.Orientation); err != nil {
+		return nil, err
+	}
+
+	// the faces are wound outwards, and the uv map reads from the inside.
+	// So flip the winding for concave walls and mirror the uv map for convex walls
+	flip := c.Orientation == OrientationConcave
+	mirror := c.Orientation == OrientationConvex
+	mu := func(u float64) float64 {
+		if mirror {
+			return 1 - u
+		}
+		return u
+	}
 
 	// get the total angle covered by the cylinder.
 	azimuthInc := (2 * math.Asin(c.TileWidth/(2*c.CurveRadius)))
+	if math.IsNaN(azimuthInc) || azimuthInc <= 0 {
+		return nil, fmt.Errorf("a tile width of %v can not be placed on a cylinder radius of %v", c.TileWidth, c.CurveRadius)
+	}
+
+	// the angle of each tile, which is the same as the
+	// azimuth increment unless the tiles are spaced out
+	tileAngle := azimuthInc
+
+	azimuthStart := -c.AzimuthMaxAngle
+	columns := math.Ceil(2 * c.AzimuthMaxAngle / azimuthInc)
+	m := &Model{Shape: c.ObjType()}
+
+	if c.ClosedRing {
+		var gap float64
+		var err error
+		columns, azimuthInc, gap, err = closedRing(c.TileWidth, c.CurveRadius)
+		if err != nil {
+			return nil, err
+		}
+
+		azimuthStart = c.SeamAngle
+
+		// report any leftover space so the tiles can be spaced out
+		if gap > 0 {
+			m.Notes = append(m.Notes, fmt.Sprintf("closed ring of %v tiles leaves a gap of %v per joint, %v in total", columns, gap, gap*columns))
+		}
+	}
 
 	z := 0.0
-	vertexCount := 1
-	azimuth := -c.AzimuthMaxAngle
+	azimuth := azimuthStart
 
-	pixelWidth := math.Ceil(2*c.AzimuthMaxAngle/azimuthInc) * c.Dx
+	pixelWidth := columns * c.Dx
 	pixelHeight := math.Ceil(c.CurveHeight/c.TileHeight) * c.Dy
 
 	// rows * column for the total expected tile count
-	tiles := make([]gridgen.Tilelayout, int(math.Ceil(2*c.AzimuthMaxAngle/azimuthInc)*math.Ceil(c.CurveHeight/c.TileHeight)))
+	tiles := make([]ModelTile, int(columns*math.Ceil(c.CurveHeight/c.TileHeight)))
 
-	uWidth := 1 / (math.Ceil(2 * c.AzimuthMaxAngle / azimuthInc))
+	uWidth := 1 / columns
 	vheight := 1 / math.Ceil(c.CurveHeight/c.TileHeight)
 	v := 0.0
 
@@ -69,56 +133,77 @@ func (c Curve) Generate(wObj, wTsig io.Writer) error {
 	for z < c.CurveHeight {
 		u := 1.0
 
-		tileFaces := ""
-		for azimuth <= c.AzimuthMaxAngle {
-			//	tileCount++
+		// loop by column count so the last column can not overshoot
+		for col := 0; col < int(columns); col++ {
 
-			// get angle change
+			// centre the tile in its share of the azimuth
+			start := azimuth + (azimuthInc-tileAngle)/2
+			end := start + tileAngle
 
-			x1, y1, z1 := CylindricalToCartesian(c.CurveRadius, z, azimuth)
-			tileFaces += fmt.Sprintf("v %v %v %v \n", x1, y1, z1)
-			tileFaces += fmt.Sprintf("vt %v %v \n", u, v)
+			x1, y1, z1 := CylindricalToCartesian(c.CurveRadius, z, start)
+			x2, y2, z2 := CylindricalToCartesian(c.CurveRadius, z, end)                // increase azimuth
+			x3, y3, z3 := CylindricalToCartesian(c.CurveRadius, z+c.TileHeight, end)   // increase azimuth and height
+			x4, y4, z4 := CylindricalToCartesian(c.CurveRadius, z+c.TileHeight, start) // increase height
 
-			x2, y2, z2 := CylindricalToCartesian(c.CurveRadius, z, azimuth+azimuthInc) // increase azimuth
-			tileFaces += fmt.Sprintf("v %v %v %v \n", x2, y2, z2)
-			tileFaces += fmt.Sprintf("vt %v %v \n", u-uWidth, v)
+			// the normals are along the radius of the cylinder
+			nx1, ny1, nz1 := CylindricalToCartesian(1, 0, start)
+			nx2, ny2, nz2 := CylindricalToCartesian(1, 0, end)
 
-			x3, y3, z3 := CylindricalToCartesian(c.CurveRadius, z+c.TileHeight, azimuth+azimuthInc) // increase azimuth and height
-			tileFaces += fmt.Sprintf("v %v %v %v \n", x3, y3, z3)
-			tileFaces += fmt.Sprintf("vt %v %v \n", u-uWidth, v+vheight)
-
-			x4, y4, z4 := CylindricalToCartesian(c.CurveRadius, z+c.TileHeight, azimuth) // increase height
-			tileFaces += fmt.Sprintf("v %v %v %v \n", x4, y4, z4)
-			tileFaces += fmt.Sprintf("vt %v %v \n", u, v+vheight)
-
-			tileFaces += fmt.Sprintf("f %v/%v %v/%v %v/%v %v/%v\n", vertexCount, vertexCount, vertexCount+1, vertexCount+1, vertexCount+2, vertexCount+2, vertexCount+3, vertexCount+3)
+			tiles[tileCount] = ModelTile{Flip: flip,
+				Corners: [4][3]float64{{x1, y1, z1}, {x2, y2, z2}, {x3, y3, z3}, {x4, y4, z4}},
+				UVs:     [4][2]float64{{mu(u), v}, {mu(u - uWidth), v}, {mu(u - uWidth), v + vheight}, {mu(u), v + vheight}},
+				Normals: [4][3]float64{{nx1, ny1, nz1}, {nx2, ny2, nz2}, {nx2, ny2, nz2}, {nx1, ny1, nz1}},
+			}
 
 			azimuth += azimuthInc
 			u -= uWidth
-			vertexCount += 4
 
-			tiles[tileCount] = gridgen.Tilelayout{Layout: gridgen.Positions{Flat: gridgen.XY{X: int(math.Round(u * pixelWidth)), Y: int(math.Round((1 - (v + vheight)) * pixelHeight))}, Size: gridgen.XY{X: int(c.Dx), Y: int(c.Dy)}}}
+			tiles[tileCount].Flat = gridgen.XY{X: int(math.Round(u * pixelWidth)), Y: int(math.Round((1 - (v + vheight)) * pixelHeight))}
+			tiles[tileCount].Size = gridgen.XY{X: int(c.Dx), Y: int(c.Dy)}
+			if mirror {
+				tiles[tileCount].Flat.X = int(pixelWidth) - tiles[tileCount].Flat.X - int(c.Dx)
+			}
 
 			tileCount++
 		}
 
-		_, err := wObj.Write([]byte(tileFaces))
-		if err != nil {
-			return fmt.Errorf("error writing to obj %v", err)
-		}
-
 		// increase the z height
 		// as well as the uv map height
 		v += vheight
 		z += c.TileHeight
 		// reset the azimuth to the start point
-		azimuth = -c.AzimuthMaxAngle
+		azimuth = azimuthStart
+	}
+
+	gridFromFlat(tiles)
+	m.Tiles = tiles
+	m.Flat = gridgen.XY2D{X0: 0, X1: int(pixelWidth), Y0: 0, Y1: int(pixelHeight)}
+	describeTiles(m)
+
+	return m, nil
+}
+
+// closedRing calculates the column count and azimuth increment that closes
+// a full circle of the given radius with tiles of the given width.
+// The gap is the chord between neighbouring tiles, when each tile is centred
+// in its azimuth increment and the tile width is not a factor of the circumference.
+func closedRing(tileWidth, radius float64) (columns, azimuthInc, gap float64, err error) {
+	tileAngle := 2 * math.Asin(tileWidth/(2*radius))
+	if math.IsNaN(tileAngle) || tileAngle <= 0 {
+		return 0, 0, 0, fmt.Errorf("a tile width of %v can not be placed on a radius of %v", tileWidth, radius)
 	}
 
-	tsig := gridgen.TPIG{Tilelayout: tiles, Dimensions: gridgen.Dimensions{Flat: gridgen.XY2D{X0: 0, X1: int(pixelWidth), Y0: 0, Y1: int(pixelHeight)}}}
+	// allow for floating point errors when the tiles fit exactly
+	columns = math.Floor(2*math.Pi/tileAngle + 1e-9)
+	if columns < 3 {
+		return 0, 0, 0, fmt.Errorf("a tile width of %v only fits %v tiles around a radius of %v, at least 3 are required to close a ring", tileWidth, columns, radius)
+	}
 
-	enc := json.NewEncoder(wTsig)
-	enc.SetIndent("", "    ")
-	return enc.Encode(tsig)
+	azimuthInc = 2 * math.Pi / columns
+	gap = 2 * radius * math.Sin((azimuthInc-tileAngle)/2)
+	if gap < 1e-9 {
+		gap = 0
+	}
 
+	return columns, azimuthInc, gap, nil
 }
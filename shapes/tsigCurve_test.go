@@ -0,0 +1,76 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"math"
+	"testing"
+)
+
+func TestClosedRing(t *testing.T) {
+
+	for _, tc := range []struct {
+		name              string
+		tileWidth, radius float64
+		columns           float64
+		err               bool
+	}{
+		// a chord of 1 is a sixth of a circle of radius 1
+		{name: "exact fit", tileWidth: 1, radius: 1, columns: 6},
+		{name: "with gaps", tileWidth: 0.9, radius: 1, columns: 6},
+		{name: "many tiles", tileWidth: 0.5, radius: 5, columns: 62},
+		{name: "only two tiles", tileWidth: 1.9, radius: 1, err: true},
+		{name: "wider than the circle", tileWidth: 3, radius: 1, err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			columns, azimuthInc, gap, err := closedRing(tc.tileWidth, tc.radius)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("got %v columns, want an error", columns)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if columns != tc.columns {
+				t.Errorf("got %v columns, want %v", columns, tc.columns)
+			}
+
+			if math.Abs(columns*azimuthInc-2*math.Pi) > 1e-9 {
+				t.Errorf("%v columns of %v radians do not close the ring", columns, azimuthInc)
+			}
+
+			// the tiles and the gaps between them go round the circle once
+			tileAngle := 2 * math.Asin(tc.tileWidth/(2*tc.radius))
+			gapAngle := 2 * math.Asin(gap/(2*tc.radius))
+			if math.Abs(columns*(tileAngle+gapAngle)-2*math.Pi) > 1e-9 {
+				t.Errorf("a gap of %v between %v tiles does not close the ring", gap, columns)
+			}
+		})
+	}
+}
+
+func TestClosedRingTiles(t *testing.T) {
+
+	c := Curve{TileHeight: 0.5, TileWidth: 0.5, CurveRadius: 5, CurveHeight: 1, ClosedRing: true, Dx: 50, Dy: 50}
+	m, err := c.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.Tiles) != 62*2 {
+		t.Fatalf("got %v tiles, want %v", len(m.Tiles), 62*2)
+	}
+
+	// the tiles keep their width, rather than being stretched to close the ring
+	for _, tile := range m.Tiles {
+		if w := distance(tile.Corners[0], tile.Corners[1]); math.Abs(w-c.TileWidth) > 1e-9 {
+			t.Errorf("%s is %v wide, want %v", tile.Name, w, c.TileWidth)
+		}
+	}
+}
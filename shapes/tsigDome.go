@@ -0,0 +1,270 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/mrmxf/opentsg-modules/opentsg-core/gridgen"
+)
+
+// add the shape to the main handler here
+func init() {
+	AddShapeToHandler[Dome]("A full hemisphere dome, with a tiled polar cap")
+}
+
+// the default minimum tiles in a ring before
+// the polar cap is used.
+const domeMinRingTiles = 12
+
+// Dome properties
+type Dome struct {
+	// dimensions of the tiles
+	TileHeight float64 `json:"tileHeight" yaml:"tileHeight"`
+	TileWidth  float64 `json:"tileWidth" yaml:"tileWidth"`
+	// physical properties of the dome
+	Radius float64 `json:"radius" yaml:"radius"`
+	// MinRingTiles is the least amount of tiles a ring can have,
+	// when a ring would have fewer tiles then the rest of the
+	// dome is covered by the polar cap. Defaults to 12.
+	MinRingTiles int `json:"minRingTiles" yaml:"minRingTiles"`
+	// pixels in each direction of the tile
+	Dx float64 `json:"dx" yaml:"dx"`
+	Dy float64 `json:"dy" yaml:"dy"`
+	// Orientation is "concave" for domes viewed from the inside, or "convex"
+	// for domes viewed from the outside. Defaults to concave.
+	Orientation string `json:"orientation" yaml:"orientation"`
+	// shape name of "dome"
+	ShapeName
+}
+
+// Returns the name of the object
+func (d Dome) ObjType() string {
+	return "dome"
+}
+
+// domeTile is the corners of a tile, as seen from the inside of the dome,
+// and the tile's row and column in the flat layout.
+type domeTile struct {
+	// bottom left, bottom right, top right, top left
+	corners  [4][3]float64
+	row, col int
+}
+
+// Generate generates a TSIG and OBJ for a full hemisphere dome.
+func (d Dome) Generate(wObj, wTsig io.Writer) error {
+	return generate(d, wObj, wTsig)
+}
+
+/*
+Build builds the model of a full hemisphere, from the equator
+to the pole, with 360 degrees of azimuth. The dome is centred on 0,0,0.
+
+The dome is made of rings of tiles, each ring has its own integer count of tiles
+so the ring is closed. When the rings get too small near the pole, the pole is
+covered by a cap of tiles laid out on a square grid, instead of rings of slivers.
+
+The tiles in the TSIG are ordered by ring, starting at the equator, with the polar
+cap last.
+*/
+func (d Dome) Build() (*Model, error) {
+
+	if err := orientationFence(d.Orientation); err != nil {
+		return nil, err
+	}
+
+	if d.TileWidth <= 0 || d.TileHeight <= 0 || d.Radius <= 0 {
+		return nil, fmt.Errorf("the tile dimensions and radius must be greater than 0")
+	}
+
+	minRing := d.MinRingTiles
+	if minRing == 0 {
+		minRing = domeMinRingTiles
+	}
+
+	if minRing < 3 {
+		return nil, fmt.Errorf("a ring needs at least 3 tiles, got a minimum of %v", minRing)
+	}
+
+	thetaInc := 2 * (math.Asin(d.TileHeight / (2 * d.Radius)))
+	if math.IsNaN(thetaInc) {
+		return nil, fmt.Errorf("a tile height of %v can not be placed on a radius of %v", d.TileHeight, d.Radius)
+	}
+
+	rings := [][]domeTile{}
+	maxColumns := 0
+	// theta of the top of the last ring
+	capTheta := math.Pi / 2
+
+	for ring := 0; ; ring++ {
+		thetaBot := math.Pi/2 - float64(ring)*thetaInc
+		thetaTop := thetaBot - thetaInc
+
+		if thetaTop <= 0 {
+			break
+		}
+
+		// fit the tiles to the smaller top of the ring
+		aTop := 2 * math.Asin(d.TileWidth/(2*d.Radius*math.Sin(thetaTop)))
+		aBot := 2 * math.Asin(d.TileWidth/(2*d.Radius*math.Sin(thetaBot)))
+		if math.IsNaN(aTop) || math.IsNaN(aBot) {
+			break
+		}
+
+		columns := int(math.Floor(2*math.Pi/aTop + 1e-9))
+		if columns < minRing {
+			break
+		}
+
+		// spread the tiles evenly around the ring
+		azimuthInc := 2 * math.Pi / float64(columns)
+		tiles := make([]domeTile, columns)
+		for col := range tiles {
+			centre := (float64(col) + 0.5) * azimuthInc
+			var t domeTile
+			// the azimuth increases to the left, when viewed from the inside
+			t.corners[0] = polar(d.Radius, thetaBot, centre+aBot/2)
+			t.corners[1] = polar(d.Radius, thetaBot, centre-aBot/2)
+			t.corners[2] = polar(d.Radius, thetaTop, centre-aTop/2)
+			t.corners[3] = polar(d.Radius, thetaTop, centre+aTop/2)
+			t.row = ring
+			// so lay the columns out from the right
+			t.col = columns - 1 - col
+			tiles[col] = t
+		}
+
+		rings = append(rings, tiles)
+		maxColumns = max(maxColumns, columns)
+		capTheta = thetaTop
+	}
+
+	capTiles, capColumns, capRows := d.polarCap(capTheta)
+	maxColumns = max(maxColumns, capColumns)
+
+	rows := len(rings) + capRows
+	pixelWidth := float64(maxColumns) * d.Dx
+	pixelHeight := float64(rows) * d.Dy
+
+	convex := d.Orientation == OrientationConvex
+	tiles := []ModelTile{}
+
+	// each row is centred on the flat layout
+	layout := func(dts []domeTile, columns, rowOffset int, face string) {
+		colOffset := (maxColumns - columns) / 2
+
+		for _, t := range dts {
+			flatX := float64(colOffset+t.col) * d.Dx
+			flatY := float64(rows-1-(t.row+rowOffset)) * d.Dy
+
+			u0, u1 := flatX/pixelWidth, (flatX+d.Dx)/pixelWidth
+			v0, v1 := 1-(flatY+d.Dy)/pixelHeight, 1-flatY/pixelHeight
+			uvs := [4][2]float64{{u0, v0}, {u1, v0}, {u1, v1}, {u0, v1}}
+
+			if convex {
+				// mirror the uv map so it reads from the outside
+				for i := range uvs {
+					uvs[i][0] = 1 - uvs[i][0]
+				}
+				flatX = pixelWidth - flatX - d.Dx
+			}
+
+			// the corners are anticlockwise from the inside so face
+			// inwards, flip them to face outwards. The normals are along the radius.
+			tiles = append(tiles, ModelTile{Face: face, Row: t.row, Col: t.col, Corners: t.corners, UVs: uvs, Normals: t.corners, Flip: convex,
+				Flat: gridgen.XY{X: int(math.Round(flatX)), Y: int(math.Round(flatY))}, Size: gridgen.XY{X: int(d.Dx), Y: int(d.Dy)}})
+		}
+	}
+
+	for _, ring := range rings {
+		layout(ring, len(ring), 0, "")
+	}
+
+	layout(capTiles, capColumns, len(rings), "cap")
+
+	m := &Model{Shape: d.ObjType(), Tiles: tiles, Flat: gridgen.XY2D{X0: 0, X1: int(pixelWidth), Y0: 0, Y1: int(pixelHeight)}}
+	describeTiles(m)
+
+	return m, nil
+}
+
+/*
+polarCap tiles the pole of the dome, above the inclination capTheta.
+
+The tiles are laid out on a square grid centred on the pole,
+which is projected onto the sphere with an azimuthal equidistant projection,
+so the distances from the pole are kept. Only the tiles that fit within
+the cap are kept. The grid is orientated so it reads upright
+when looking up from the origin, while facing the azimuth of pi. That is
+the middle of the rings in the flat layout, so the centre columns of the
+cap join the top ring where they touch on the dome.
+*/
+func (d Dome) polarCap(capTheta float64) (tiles []domeTile, columns, rows int) {
+
+	capRadius := d.Radius * capTheta
+	columns = int(math.Floor(2 * capRadius / d.TileWidth))
+	rows = int(math.Floor(2 * capRadius / d.TileHeight))
+
+	// a is to the right and b is up, when viewed from the inside
+	aStart := -float64(columns) * d.TileWidth / 2
+	bStart := -float64(rows) * d.TileHeight / 2
+
+	inCap := func(a, b float64) bool {
+		return math.Hypot(a, b) <= capRadius+1e-9
+	}
+
+	usedRows := map[int]bool{}
+	for row := 0; row < rows; row++ {
+		for col := 0; col < columns; col++ {
+			a, b := aStart+float64(col)*d.TileWidth, bStart+float64(row)*d.TileHeight
+
+			planar := [4][2]float64{{a, b}, {a + d.TileWidth, b}, {a + d.TileWidth, b + d.TileHeight}, {a, b + d.TileHeight}}
+
+			var t domeTile
+			fits := true
+			for i, p := range planar {
+				if !inCap(p[0], p[1]) {
+					fits = false
+					break
+				}
+				// facing the azimuth of pi and looking up, right is y and up is x
+				x, y := p[1], p[0]
+				t.corners[i] = polar(d.Radius, math.Hypot(x, y)/d.Radius, math.Atan2(y, x))
+			}
+
+			if !fits {
+				continue
+			}
+
+			t.row, t.col = row, col
+			tiles = append(tiles, t)
+			usedRows[row] = true
+		}
+	}
+
+	// remove any empty rows from the flat layout
+	shift := 0
+	rowShift := make([]int, rows)
+	for row := 0; row < rows; row++ {
+		if !usedRows[row] {
+			shift++
+		}
+		rowShift[row] = shift
+	}
+
+	for i := range tiles {
+		tiles[i].row -= rowShift[tiles[i].row]
+	}
+
+	return tiles, columns, rows - shift
+}
+
+// polar returns the cartesian point of the polar coordinates.
+func polar(r, theta, phi float64) [3]float64 {
+	x, y, z := PolarToCartesian(r, theta, phi)
+	return [3]float64{x, y, z}
+}
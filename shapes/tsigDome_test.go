@@ -0,0 +1,86 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mrmxf/opentsg-modules/opentsg-core/gridgen"
+)
+
+func TestDome(t *testing.T) {
+
+	small := Dome{TileHeight: 0.5, TileWidth: 0.5, Radius: 3, Dx: 10, Dy: 10}
+
+	for _, tc := range []struct {
+		name string
+		dome func(d *Dome)
+		// the tiles in each ring, from the equator, and in the polar cap
+		rings []int
+		cap   int
+		flat  gridgen.XY2D
+		err   string
+	}{
+		{name: "default minimum ring", dome: func(d *Dome) {},
+			rings: []int{37, 35, 33, 29, 25, 20, 14}, cap: 12, flat: gridgen.XY2D{X1: 370, Y1: 110}},
+		{name: "smaller minimum ring", dome: func(d *Dome) { d.MinRingTiles = 6 },
+			rings: []int{37, 35, 33, 29, 25, 20, 14, 8}, cap: 4, flat: gridgen.XY2D{X1: 370, Y1: 100}},
+		{name: "all polar cap", dome: func(d *Dome) { d.MinRingTiles = 40 },
+			cap: 240, flat: gridgen.XY2D{X1: 180, Y1: 180}},
+		{name: "convex", dome: func(d *Dome) { d.Orientation = OrientationConvex },
+			rings: []int{37, 35, 33, 29, 25, 20, 14}, cap: 12, flat: gridgen.XY2D{X1: 370, Y1: 110}},
+		{name: "tall tiles", dome: func(d *Dome) { d.TileHeight, d.Radius, d.Dy = 1, 5, 20 },
+			rings: []int{61, 57, 51, 43, 33, 22}, cap: 13, flat: gridgen.XY2D{X1: 610, Y1: 180}},
+		{name: "no radius", dome: func(d *Dome) { d.Radius = 0 },
+			err: "must be greater than 0"},
+		{name: "ring of two", dome: func(d *Dome) { d.MinRingTiles = 2 },
+			err: "a ring needs at least 3 tiles"},
+		{name: "tiles taller than the dome", dome: func(d *Dome) { d.TileHeight = 7 },
+			err: "can not be placed on a radius"},
+		{name: "unknown orientation", dome: func(d *Dome) { d.Orientation = "sideways" },
+			err: "sideways"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := small
+			tc.dome(&d)
+
+			m, err := d.Build()
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("got the error %v, want an error of %q", err, tc.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rings, capTiles := []int{}, 0
+			for _, tile := range m.Tiles {
+				if tile.Face == "cap" {
+					capTiles++
+					continue
+				}
+
+				for len(rings) <= tile.Row {
+					rings = append(rings, 0)
+				}
+				rings[tile.Row]++
+			}
+
+			if !slices.Equal(rings, tc.rings) || capTiles != tc.cap || m.Flat != tc.flat {
+				t.Errorf("got rings of %v and a cap of %v tiles on a %v canvas, want %v and %v on %v", rings, capTiles, m.Flat, tc.rings, tc.cap, tc.flat)
+			}
+
+			if v := validateModel(m, 1); v.problems() > 0 {
+				t.Errorf("the dome does not fit its canvas: %v %v %v", v.Mismatches, v.Overlaps, v.Outside)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/mrmxf/opentsg-modules/opentsg-core/gridgen"
+)
+
+func init() {
+	AddShapeToHandler[FacetedCurve]("A curved wall of flat tiles, set by the angle between the tiles")
+}
+
+// FacetedCurve Properties
+type FacetedCurve struct {
+	// dimensions of the tiles
+	TileHeight float64 `json:"tileHeight" yaml:"tileHeight"`
+	TileWidth  float64 `json:"tileWidth" yaml:"tileWidth"`
+	// the number of tiles across and up the wall
+	Columns int `json:"columns" yaml:"columns"`
+	Rows    int `json:"rows" yaml:"rows"`
+	// TileAngle is the angle in degrees between neighbouring tiles,
+	// as the curve locks of the tiles are set.
+	TileAngle float64 `json:"tileAngle" yaml:"tileAngle"`
+	// JointAngles are the angles in degrees of each joint between the columns,
+	// from left to right as the flat layout is read. If they are given they
+	// are used instead of the tile angle, and there must be one less
+	// than the number of columns.
+	JointAngles []float64 `json:"jointAngles" yaml:"jointAngles"`
+	// Orientation is "concave" for walls viewed from the inside, or "convex"
+	// for walls viewed from the outside. The face winding and uv map are
+	// set so the TSIG reads left to right from the viewer's side.
+	Orientation string `json:"orientation" yaml:"orientation"`
+	// pixel count properties
+	Dx float64 `json:"dx" yaml:"dx"`
+	Dy float64 `json:"dy" yaml:"dy"`
+	// shape name of "facetedcurve"
+	ShapeName
+}
+
+func (f FacetedCurve) ObjType() string {
+	return "facetedcurve"
+}
+
+// Generate generates a TSIG and OBJ for a faceted curved wall.
+func (f FacetedCurve) Generate(wObj, wTsig io.Writer) error {
+	return generate(f, wObj, wTsig)
+}
+
+/*
+Build builds the model of a curved wall of flat tiles, where each tile is
+turned from its neighbour by the joint angle between them.
+
+The wall is laid out around the effective centre of the curve, at 0,0,0,
+in the same way as the curve shape. So a faceted curve with a tile angle of
+a is the same as a curve of the effective radius, with an azimuth increment of a.
+The effective radius is the radius of the arc through the end joints that turns
+through the same angle as the tiles, for varying joint angles. A wall with no
+turn is flat, and centred on 0,0,0.
+
+The effective radius and the angle of each joint are reported in the notes of the model.
+*/
+func (f FacetedCurve) Build() (*Model, error) {
+
+	if err := orientationFence(f.Orientation); err != nil {
+		return nil, err
+	}
+
+	if f.TileWidth <= 0 || f.TileHeight <= 0 {
+		return nil, fmt.Errorf("tile dimensions must be greater than 0, got a width of %v and a height of %v", f.TileWidth, f.TileHeight)
+	}
+
+	if f.Columns < 1 || f.Rows < 1 {
+		return nil, fmt.Errorf("the columns and rows must be at least 1, got %v columns and %v rows", f.Columns, f.Rows)
+	}
+
+	joints := f.JointAngles
+	if joints == nil {
+		joints = make([]float64, f.Columns-1)
+		for i := range joints {
+			joints[i] = f.TileAngle
+		}
+	}
+
+	if len(joints) != f.Columns-1 {
+		return nil, fmt.Errorf("%v columns need %v joint angles, got %v", f.Columns, f.Columns-1, len(joints))
+	}
+
+	// the turn of the wall, from the first tile to the last
+	turn := 0.0
+	for i, a := range joints {
+		if a < 0 || a >= 90 {
+			return nil, fmt.Errorf("joint angle %v of %v degrees must be between 0 and 90 degrees", i+1, a)
+		}
+		turn += a * math.Pi / 180
+	}
+
+	// the angle of the arc spanned by the wall, counting half
+	// a joint either side of the end tiles as a curve does
+	arc := 0.0
+	if f.Columns > 1 {
+		arc = turn * float64(f.Columns) / float64(f.Columns-1)
+	}
+
+	if arc >= 2*math.Pi {
+		return nil, fmt.Errorf("the joint angles turn the wall through %.4g degrees, so the tiles overlap", arc*180/math.Pi)
+	}
+
+	// the flat layout reads clockwise as seen from above,
+	// unless it is mirrored for convex walls
+	mirror := f.Orientation == OrientationConvex
+	walkJoint := func(i int) float64 {
+		if mirror {
+			return joints[i]
+		}
+		return joints[len(joints)-1-i]
+	}
+
+	// walk the joints along the wall, anticlockwise as
+	// seen from above, turning left at each joint
+	points := make([][2]float64, f.Columns+1)
+	heading := 0.0
+	for i := 0; i < f.Columns; i++ {
+		points[i+1] = [2]float64{points[i][0] + f.TileWidth*math.Cos(heading), points[i][1] + f.TileWidth*math.Sin(heading)}
+		if i < len(joints) {
+			heading += walkJoint(i) * math.Pi / 180
+		}
+	}
+
+	// turn the wall so the chord between the end joints runs along y,
+	// then move the middle of the chord to where it would be on the arc
+	chord := [2]float64{points[f.Columns][0] - points[0][0], points[f.Columns][1] - points[0][1]}
+	chordLength := math.Hypot(chord[0], chord[1])
+	rotate := math.Pi/2 - math.Atan2(chord[1], chord[0])
+
+	radius := math.Inf(1)
+	middle := [2]float64{}
+	if arc > 0 {
+		radius = chordLength / (2 * math.Sin(arc/2))
+		middle[0] = radius * math.Cos(arc/2)
+	}
+
+	sin, cos := math.Sin(rotate), math.Cos(rotate)
+	mid := [2]float64{(points[0][0] + points[f.Columns][0]) / 2, (points[0][1] + points[f.Columns][1]) / 2}
+	for i, p := range points {
+		x, y := p[0]-mid[0], p[1]-mid[1]
+		points[i] = [2]float64{x*cos - y*sin + middle[0], x*sin + y*cos + middle[1]}
+	}
+
+	// the faces are wound outwards, and the uv map reads from the inside.
+	// So flip the winding for concave walls and mirror the uv map for convex walls
+	flip := f.Orientation == OrientationConcave
+	mu := func(u float64) float64 {
+		if mirror {
+			return 1 - u
+		}
+		return u
+	}
+
+	columns, rows := float64(f.Columns), float64(f.Rows)
+	pixelWidth := columns * f.Dx
+	pixelHeight := rows * f.Dy
+
+	uWidth := 1 / columns
+	vHeight := 1 / rows
+
+	tiles := make([]ModelTile, 0, f.Rows*f.Columns)
+	for row := 0; row < f.Rows; row++ {
+		z := float64(row) * f.TileHeight
+		v := float64(row) * vHeight
+
+		for col := 0; col < f.Columns; col++ {
+			a, b := points[col], points[col+1]
+			u := 1 - float64(col)*uWidth
+
+			t := ModelTile{Flip: flip,
+				Corners: [4][3]float64{{a[0], a[1], z}, {b[0], b[1], z}, {b[0], b[1], z + f.TileHeight}, {a[0], a[1], z + f.TileHeight}},
+				UVs:     [4][2]float64{{mu(u), v}, {mu(u - uWidth), v}, {mu(u - uWidth), v + vHeight}, {mu(u), v + vHeight}},
+				Flat:    gridgen.XY{X: int(math.Round((u - uWidth) * pixelWidth)), Y: int(math.Round((1 - (v + vHeight)) * pixelHeight))},
+				Size:    gridgen.XY{X: int(f.Dx), Y: int(f.Dy)},
+			}
+
+			if mirror {
+				t.Flat.X = int(pixelWidth) - t.Flat.X - int(f.Dx)
+			}
+
+			tiles = append(tiles, t)
+		}
+	}
+
+	gridFromFlat(tiles)
+	m := &Model{Shape: f.ObjType(), Tiles: tiles, Flat: gridgen.XY2D{X0: 0, X1: int(pixelWidth), Y0: 0, Y1: int(pixelHeight)}}
+	describeTiles(m)
+
+	// report the curve for rigging
+	if math.IsInf(radius, 1) {
+		m.Notes = append(m.Notes, "the wall is flat, with no turn between the tiles")
+	} else {
+		m.Notes = append(m.Notes, fmt.Sprintf("effective radius of %.6g to the tile joints and %.6g to the tile centres, over an arc of %.6g degrees",
+			radius, math.Sqrt(radius*radius-f.TileWidth*f.TileWidth/4), arc*180/math.Pi))
+	}
+
+	for i, a := range joints {
+		// the columns either side of the joint, named by the bottom row
+		m.Notes = append(m.Notes, fmt.Sprintf("joint %v between %s and %s is %v degrees", i+1, tileName(m.Shape, ModelTile{Col: i}), tileName(m.Shape, ModelTile{Col: i + 1}), a))
+	}
+
+	return m, nil
+}
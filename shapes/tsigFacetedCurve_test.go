@@ -0,0 +1,107 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestFacetedCurve(t *testing.T) {
+
+	base := FacetedCurve{TileWidth: 0.5, TileHeight: 0.5, Columns: 6, Rows: 2, Dx: 10, Dy: 10}
+
+	for _, tc := range []struct {
+		name  string
+		curve func(f *FacetedCurve)
+		// the angles between the columns from left to right, as the flat
+		// layout is read, and the radius the joints are on, 0 for a flat wall
+		joints []float64
+		radius float64
+		err    string
+	}{
+		{name: "tile angle", curve: func(f *FacetedCurve) { f.TileAngle = 10 },
+			joints: []float64{10, 10, 10, 10, 10}, radius: 0.25 / math.Sin(5*math.Pi/180)},
+		{name: "convex", curve: func(f *FacetedCurve) { f.TileAngle, f.Orientation = 10, OrientationConvex },
+			joints: []float64{10, 10, 10, 10, 10}, radius: 0.25 / math.Sin(5*math.Pi/180)},
+		{name: "flat", curve: func(f *FacetedCurve) {},
+			joints: []float64{0, 0, 0, 0, 0}},
+		{name: "joint angles", curve: func(f *FacetedCurve) { f.JointAngles = []float64{10, 5, 20, 0, 0} },
+			joints: []float64{10, 5, 20, 0, 0}},
+		{name: "convex joint angles", curve: func(f *FacetedCurve) {
+			f.JointAngles, f.Orientation = []float64{0, 5, 20, 5, 10}, OrientationConvex
+		}, joints: []float64{0, 5, 20, 5, 10}},
+		{name: "one column", curve: func(f *FacetedCurve) { f.Columns, f.TileAngle = 1, 10 }},
+		{name: "too few joint angles", curve: func(f *FacetedCurve) { f.JointAngles = []float64{5, 5} },
+			err: "6 columns need 5 joint angles, got 2"},
+		{name: "right angle joint", curve: func(f *FacetedCurve) { f.TileAngle = 90 },
+			err: "must be between 0 and 90 degrees"},
+		{name: "turns all the way round", curve: func(f *FacetedCurve) { f.Columns, f.TileAngle = 40, 10 },
+			err: "so the tiles overlap"},
+		{name: "no rows", curve: func(f *FacetedCurve) { f.Rows = 0 },
+			err: "must be at least 1"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := base
+			tc.curve(&f)
+
+			m, err := f.Build()
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("got the error %v, want an error of %q", err, tc.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(m.Tiles) != f.Columns*f.Rows {
+				t.Fatalf("got %v tiles, want %v", len(m.Tiles), f.Columns*f.Rows)
+			}
+
+			// the bottom row of tiles from left to right, as the flat layout is read
+			bottom := make([]ModelTile, f.Columns)
+			for _, tile := range m.Tiles {
+				if tile.Row == 0 {
+					bottom[tile.Col] = tile
+				}
+			}
+
+			for col, tile := range bottom {
+				if width := distance(tile.Corners[0], tile.Corners[1]); math.Abs(width-f.TileWidth) > 1e-9 {
+					t.Errorf("%s is %v wide, want %v", tile.Name, width, f.TileWidth)
+				}
+
+				if tc.radius != 0 {
+					for _, c := range tile.Corners[:2] {
+						if r := math.Hypot(c[0], c[1]); math.Abs(r-tc.radius) > 1e-9 {
+							t.Errorf("%s has a corner %v from the centre, want %v", tile.Name, r, tc.radius)
+						}
+					}
+				}
+
+				if col == 0 {
+					continue
+				}
+
+				// the turn between the faces of the tiles
+				a, b := bottom[col-1].Corners, tile.Corners
+				da, db := sub(a[1], a[0]), sub(b[1], b[0])
+				turn := math.Acos(math.Max(-1, math.Min(1, dot(da, db)/(distance(a[0], a[1])*distance(b[0], b[1]))))) * 180 / math.Pi
+				if math.Abs(turn-tc.joints[col-1]) > 1e-6 {
+					t.Errorf("the joint between %s and %s turns %v degrees, want %v", bottom[col-1].Name, tile.Name, turn, tc.joints[col-1])
+				}
+			}
+
+			if v := validateModel(m, 0); v.problems() > 0 || v.UncoveredPixels > 0 {
+				t.Errorf("the wall does not fit its canvas: %v %v %v %v", v.Mismatches, v.Overlaps, v.Outside, v.Uncovered)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/mrmxf/opentsg-modules/opentsg-core/gridgen"
+)
+
+// add the shape to the main handler here
+func init() {
+	AddShapeToHandler[FlatWall]("A flat rectangular wall")
+}
+
+// FlatWall properties
+type FlatWall struct {
+	// dimensions of the tiles
+	TileHeight float64 `json:"tileHeight" yaml:"tileHeight"`
+	TileWidth  float64 `json:"tileWidth" yaml:"tileWidth"`
+	// x dimension
+	WallWidth float64 `json:"wallWidth" yaml:"wallWidth"`
+	// z dimension
+	WallHeight float64 `json:"wallHeight" yaml:"wallHeight"`
+	// pixels per direction
+	Dx float64 `json:"dx" yaml:"dx"`
+	Dy float64 `json:"dy" yaml:"dy"`
+	// shape name of "flatwall"
+	ShapeName
+}
+
+func (f FlatWall) ObjType() string {
+	return "flatwall"
+}
+
+// Generate generates a TSIG and OBJ for a flat rectangular wall.
+func (f FlatWall) Generate(wObj, wTsig io.Writer) error {
+	return generate(f, wObj, wTsig)
+}
+
+/*
+Build builds the model of a flat rectangular wall.
+The wall is in the x z plane, starting at 0,0,0 and
+facing the negative y direction.
+
+  - Width is the x plane
+
+  - Height is the z plane
+
+    Errors will be returned if the tiles do not fit exactly into the dimensions. E.g. a tile width of 1 is given and the wall has a width of 3.5
+*/
+func (f FlatWall) Build() (*Model, error) {
+
+	err := flatWallFence(f.TileHeight, f.TileWidth, f.WallWidth, f.WallHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := int(math.Round(f.WallWidth / f.TileWidth))
+	rows := int(math.Round(f.WallHeight / f.TileHeight))
+
+	// get the dimensions of the flat display.
+	pixelWidth := float64(columns) * f.Dx
+	pixelHeight := float64(rows) * f.Dy
+
+	tiles := make([]ModelTile, rows*columns)
+
+	// calculate the uv map steps in each direction
+	uStep := 1 / float64(columns)
+	vStep := 1 / float64(rows)
+
+	tileCount := 0
+
+	for j := 0; j < rows; j++ {
+		z := float64(j) * f.TileHeight
+		v := float64(j) * vStep
+
+		for i := 0; i < columns; i++ {
+			x := float64(i) * f.TileWidth
+			u := float64(i) * uStep
+
+			tiles[tileCount] = ModelTile{Row: j, Col: i,
+				Corners: [4][3]float64{{x, 0, z}, {x + f.TileWidth, 0, z}, {x + f.TileWidth, 0, z + f.TileHeight}, {x, 0, z + f.TileHeight}},
+				UVs:     [4][2]float64{{u, v}, {u + uStep, v}, {u + uStep, v + vStep}, {u, v + vStep}},
+				Flat:    gridgen.XY{X: int(math.Round(u * pixelWidth)), Y: int(math.Round((1 - (v + vStep)) * pixelHeight))},
+				Size:    gridgen.XY{X: int(f.Dx), Y: int(f.Dy)},
+			}
+
+			tileCount++
+		}
+	}
+
+	m := &Model{Shape: f.ObjType(), Tiles: tiles, Flat: gridgen.XY2D{X0: 0, X1: int(pixelWidth), Y0: 0, Y1: int(pixelHeight)}}
+	describeTiles(m)
+
+	return m, nil
+}
+
+func flatWallFence(tileHeight, tileWidth, wallWidth, wallHeight float64) error {
+
+	if tileWidth <= 0 || tileHeight <= 0 {
+		return fmt.Errorf("tile dimensions must be greater than 0, got a width of %v and a height of %v", tileWidth, tileHeight)
+	}
+
+	// check the dimensions, allowing for floating point rounding
+	if math.Abs(wallWidth/tileWidth-math.Round(wallWidth/tileWidth)) > 1e-9 || wallWidth <= 0 {
+		return fmt.Errorf("tile width of %v is not an integer multiple of a wall width of %v", tileWidth, wallWidth)
+	}
+
+	if math.Abs(wallHeight/tileHeight-math.Round(wallHeight/tileHeight)) > 1e-9 || wallHeight <= 0 {
+		return fmt.Errorf("tile height of %v is not an integer multiple of a wall height of %v", tileHeight, wallHeight)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mrmxf/opentsg-modules/opentsg-core/gridgen"
+)
+
+func TestFlatWall(t *testing.T) {
+
+	for _, tc := range []struct {
+		name string
+		wall FlatWall
+		// the canvas and the last tile, which is the top right of the wall
+		flat    gridgen.XY2D
+		tiles   int
+		last    string
+		lastAt  gridgen.XY
+		topLeft [3]float64
+		err     string
+	}{
+		{name: "single tile", wall: FlatWall{TileWidth: 1, TileHeight: 1, WallWidth: 1, WallHeight: 1, Dx: 10, Dy: 10},
+			flat: gridgen.XY2D{X1: 10, Y1: 10}, tiles: 1, last: "flatwall/r0c0", topLeft: [3]float64{0, 0, 1}},
+		{name: "3x2 wall", wall: FlatWall{TileWidth: 1, TileHeight: 1, WallWidth: 3, WallHeight: 2, Dx: 10, Dy: 10},
+			flat: gridgen.XY2D{X1: 30, Y1: 20}, tiles: 6, last: "flatwall/r1c2", lastAt: gridgen.XY{X: 20}, topLeft: [3]float64{2, 0, 2}},
+		{name: "rectangular tiles", wall: FlatWall{TileWidth: 0.5, TileHeight: 0.25, WallWidth: 2, WallHeight: 0.5, Dx: 96, Dy: 48},
+			flat: gridgen.XY2D{X1: 384, Y1: 96}, tiles: 8, last: "flatwall/r1c3", lastAt: gridgen.XY{X: 288}, topLeft: [3]float64{1.5, 0, 0.5}},
+		{name: "width is not a multiple of the tile", wall: FlatWall{TileWidth: 1, TileHeight: 1, WallWidth: 3.5, WallHeight: 2, Dx: 10, Dy: 10},
+			err: "is not an integer multiple of a wall width"},
+		{name: "height is not a multiple of the tile", wall: FlatWall{TileWidth: 1, TileHeight: 1, WallWidth: 3, WallHeight: 1.5, Dx: 10, Dy: 10},
+			err: "is not an integer multiple of a wall height"},
+		{name: "no tile size", wall: FlatWall{WallWidth: 3, WallHeight: 2, Dx: 10, Dy: 10},
+			err: "tile dimensions must be greater than 0"},
+		{name: "no wall", wall: FlatWall{TileWidth: 1, TileHeight: 1, Dx: 10, Dy: 10},
+			err: "is not an integer multiple of a wall width"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := tc.wall.Build()
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("got the error %v, want an error of %q", err, tc.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if m.Flat != tc.flat || len(m.Tiles) != tc.tiles {
+				t.Fatalf("got a %v canvas of %v tiles, want a %v canvas of %v tiles", m.Flat, len(m.Tiles), tc.flat, tc.tiles)
+			}
+
+			last := m.Tiles[len(m.Tiles)-1]
+			if last.Name != tc.last || last.Flat != tc.lastAt || !near(last.Corners[3], tc.topLeft) {
+				t.Errorf("the last tile is %s at %v with a top left corner of %v, want %s at %v with %v",
+					last.Name, last.Flat, last.Corners[3], tc.last, tc.lastAt, tc.topLeft)
+			}
+
+			if v := validateModel(m, 0); v.problems() > 0 || v.UncoveredPixels > 0 {
+				t.Errorf("the wall does not fit its canvas: %v %v %v %v", v.Mismatches, v.Overlaps, v.Outside, v.Uncovered)
+			}
+		})
+	}
+}
@@ -0,0 +1,422 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+
+	"github.com/mrmxf/opentsg-modules/opentsg-core/gridgen"
+	"github.com/spf13/cobra"
+)
+
+// add the command and its flags to the main handler
+func init() {
+	cmdFrustum.Flags().StringVar(&objFile, "obj", "", "The obj file the camera sees")
+	cmdFrustum.Flags().StringVar(&tsigFile, "tsig", "", "The TSIG file of the obj")
+	cmdFrustum.Flags().StringVar(&outFile, "outputFile", "./output", "The name of the output report, without the extension")
+	cmdFrustum.Flags().StringVar(&cameraPosition, "camera", "", "The position of the camera as x,y,z")
+	cmdFrustum.Flags().StringVar(&lookAt, "lookAt", "", "The point the camera looks at as x,y,z, the default is the centre of the obj")
+	cmdFrustum.Flags().Float64Var(&cameraRoll, "roll", 0, "The roll of the camera in degrees, anticlockwise as seen from behind the camera")
+	cmdFrustum.Flags().Float64Var(&focalLength, "focalLength", 35, "The focal length of the lens in mm")
+	cmdFrustum.Flags().Float64Var(&sensorWidth, "sensorWidth", 36, "The width of the camera sensor in mm")
+	cmdFrustum.Flags().Float64Var(&sensorHeight, "sensorHeight", 24, "The height of the camera sensor in mm")
+	cmdFrustum.Flags().BoolVar(&frustumMaskPNG, "mask", false, "Write a png mask of the flat canvas in the frustum")
+	cmdFrustum.Flags().BoolVar(&frustumSubset, "subset", false, "Write a TSIG of only the tiles in the frustum")
+
+	cmdBoth.AddCommand(cmdFrustum)
+}
+
+// frustum flags
+var (
+	cameraPosition = ""
+	cameraRoll     = 0.0
+	focalLength    = 35.0
+	sensorWidth    = 36.0
+	sensorHeight   = 24.0
+	frustumMaskPNG = false
+	frustumSubset  = false
+)
+
+var cmdFrustum = &cobra.Command{
+	Use:   "frustum",
+	Short: "find the tiles and canvas pixels a camera sees",
+	Long: `
+	Find the tiles of an obj in the view frustum of a camera, from its
+	position, focal length and sensor size, and the polygon of each tile
+	in the frustum on the flat canvas of the TSIG. A mask png and a TSIG
+	of only the tiles in the frustum can also be written.
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if cameraPosition == "" {
+			return fmt.Errorf("no camera was given, set its position with --camera x,y,z")
+		}
+
+		objBytes, err := os.ReadFile(objFile)
+		if err != nil {
+			return err
+		}
+
+		fTSIG, err := os.Open(tsigFile)
+		if err != nil {
+			return err
+		}
+		defer fTSIG.Close()
+
+		model, err := modelFromOutput(objFile, bytes.NewReader(objBytes), fTSIG)
+		if err != nil {
+			return err
+		}
+		model.Handedness = previewHandedness(objBytes)
+
+		cam := frustumCamera{view: defaultCamera(model, previewUp(objBytes), fieldOfView), roll: cameraRoll,
+			focalLength: focalLength, sensor: [2]float64{sensorWidth, sensorHeight}}
+		cam.view.eye, err = parseVector(cameraPosition)
+		if err != nil {
+			return err
+		}
+
+		if lookAt != "" {
+			cam.view.target, err = parseVector(lookAt)
+			if err != nil {
+				return err
+			}
+		}
+
+		report, err := frustumRegion(model, cam)
+		if err != nil {
+			return err
+		}
+
+		fReport, err := os.Create(outFile + ".json")
+		if err != nil {
+			return err
+		}
+		defer fReport.Close()
+
+		enc := json.NewEncoder(fReport)
+		enc.SetIndent("", "    ")
+		err = enc.Encode(report)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%v of %v tiles are in the frustum, the canvas pixels %v,%v - %v,%v\n", len(report.Tiles), len(model.Tiles),
+			report.Bounds.X0, report.Bounds.Y0, report.Bounds.X1, report.Bounds.Y1)
+
+		if frustumMaskPNG {
+			fPNG, err := os.Create(outFile + "-mask.png")
+			if err != nil {
+				return err
+			}
+			defer fPNG.Close()
+
+			err = png.Encode(fPNG, frustumMask(model.Flat, report.Tiles))
+			if err != nil {
+				return err
+			}
+		}
+
+		if frustumSubset {
+			inside := map[string]bool{}
+			for _, t := range report.Tiles {
+				inside[t.Name] = true
+			}
+
+			subset := *model
+			subset.Tiles = []ModelTile{}
+			for _, t := range model.Tiles {
+				if inside[t.Name] {
+					subset.Tiles = append(subset.Tiles, t)
+				}
+			}
+
+			fSubset, err := os.Create(outFile + "-tsig.json")
+			if err != nil {
+				return err
+			}
+			defer fSubset.Close()
+
+			return writeTSIG(fSubset, &subset)
+		}
+
+		return nil
+	},
+}
+
+// frustumCamera is a tracked camera, as its lens and sensor.
+type frustumCamera struct {
+	// the position and target of the camera, the field of view is not used
+	view previewCamera
+	// roll turns the camera around the direction it looks,
+	// in degrees anticlockwise as seen from behind the camera
+	roll float64
+	// focalLength and sensor are in mm, the sensor is width x height
+	focalLength float64
+	sensor      [2]float64
+}
+
+// frustumReport is the region of the flat canvas a camera sees.
+type frustumReport struct {
+	Camera struct {
+		Position    [3]float64 `json:"position"`
+		LookAt      [3]float64 `json:"lookAt"`
+		Roll        float64    `json:"roll"`
+		FocalLength float64    `json:"focalLength"`
+		Sensor      [2]float64 `json:"sensor"`
+		// FieldOfView is the horizontal and vertical field of view in degrees
+		FieldOfView [2]float64 `json:"fieldOfView"`
+	} `json:"camera"`
+	// Tiles are the tiles in the frustum of the camera
+	Tiles []frustumTile `json:"tiles"`
+	// Outline is the edge of the view of the camera on the flat canvas,
+	// clockwise from the top left of the view.
+	Outline [][2]float64 `json:"outline"`
+	// Bounds are the pixels of the canvas the tiles in the frustum cover
+	Bounds gridgen.XY2D `json:"bounds"`
+}
+
+// frustumTile is a tile in the frustum of a camera.
+type frustumTile struct {
+	Name string `json:"name"`
+	// Coverage is the fraction of the pixels of the tile in the frustum
+	Coverage float64 `json:"coverage"`
+	// Polygon is the part of the tile in the frustum, in flat canvas pixels
+	Polygon [][2]float64 `json:"polygon"`
+}
+
+// the points along each edge of the view the outline is traced with
+const outlineSteps = 32
+
+// fieldOfView returns the horizontal and vertical half angles of the camera, as their tangents.
+func (c frustumCamera) fieldOfView() (float64, float64) {
+	return c.sensor[0] / (2 * c.focalLength), c.sensor[1] / (2 * c.focalLength)
+}
+
+// frustumFence checks the lens and sensor of the camera.
+func frustumFence(c frustumCamera) error {
+
+	if c.focalLength <= 0 {
+		return fmt.Errorf("the focal length must be greater than 0, got %vmm", c.focalLength)
+	}
+
+	if c.sensor[0] <= 0 || c.sensor[1] <= 0 {
+		return fmt.Errorf("the sensor must be greater than 0, got %vx%vmm", c.sensor[0], c.sensor[1])
+	}
+
+	return nil
+}
+
+/*
+frustumRegion finds the tiles of the model in the view frustum of the camera,
+and the part of each tile that is in the frustum, on the flat canvas.
+
+Each tile is clipped to the frustum in 3D, and the clipped corners are put on
+the flat canvas with the uv map. Tiles hidden behind other tiles are still in
+the frustum, so every tile the camera points at is found.
+
+The outline is traced by following the edges of the view onto the nearest tile
+they land on. Where an edge of the view misses the model the outline goes
+straight on to the next point that lands.
+*/
+func frustumRegion(m *Model, c frustumCamera) (frustumReport, error) {
+
+	var report frustumReport
+	if err := frustumFence(c); err != nil {
+		return report, err
+	}
+
+	right, up, forward, err := c.view.basis()
+	if err != nil {
+		return report, err
+	}
+
+	sin, cos := math.Sincos(c.roll * math.Pi / 180)
+	right, up = [3]float64{cos*right[0] + sin*up[0], cos*right[1] + sin*up[1], cos*right[2] + sin*up[2]},
+		[3]float64{cos*up[0] - sin*right[0], cos*up[1] - sin*right[1], cos*up[2] - sin*right[2]}
+
+	tx, ty := c.fieldOfView()
+	report.Camera.Position, report.Camera.LookAt, report.Camera.Roll = c.view.eye, c.view.target, c.roll
+	report.Camera.FocalLength, report.Camera.Sensor = c.focalLength, c.sensor
+	report.Camera.FieldOfView = [2]float64{2 * math.Atan(tx) * 180 / math.Pi, 2 * math.Atan(ty) * 180 / math.Pi}
+
+	// the sides of the frustum, which the inside is in front of
+	planes := []func(p [3]float64) float64{
+		func(p [3]float64) float64 { return p[2] - previewNear },
+		func(p [3]float64) float64 { return p[2]*tx - p[0] },
+		func(p [3]float64) float64 { return p[2]*tx + p[0] },
+		func(p [3]float64) float64 { return p[2]*ty - p[1] },
+		func(p [3]float64) float64 { return p[2]*ty + p[1] },
+	}
+
+	report.Tiles = []frustumTile{}
+	report.Bounds = gridgen.XY2D{X0: math.MaxInt, Y0: math.MaxInt, X1: math.MinInt, Y1: math.MinInt}
+	quads := make([][4]previewVertex, len(m.Tiles))
+	for i, t := range m.Tiles {
+		for k, p := range t.Corners {
+			d := sub(p, c.view.eye)
+			quads[i][k] = previewVertex{p: [3]float64{dot(d, right), dot(d, up), dot(d, forward)}, uv: t.UVs[k]}
+		}
+
+		poly := quads[i][:]
+		for _, plane := range planes {
+			poly = clipPlane(poly, plane)
+			if len(poly) < 3 {
+				break
+			}
+		}
+
+		if len(poly) < 3 {
+			continue
+		}
+
+		ft := frustumTile{Name: t.Name, Polygon: make([][2]float64, len(poly))}
+		for k, v := range poly {
+			ft.Polygon[k] = m.flatPixel(v.uv)
+		}
+
+		area := polygonArea(ft.Polygon)
+		if area <= 0 {
+			continue
+		}
+
+		if pixels := float64(t.Size.X * t.Size.Y); pixels > 0 {
+			ft.Coverage = math.Min(1, area/pixels)
+		}
+
+		for k, p := range ft.Polygon {
+			report.Bounds.X0, report.Bounds.X1 = min(report.Bounds.X0, int(math.Floor(p[0]))), max(report.Bounds.X1, int(math.Ceil(p[0])))
+			report.Bounds.Y0, report.Bounds.Y1 = min(report.Bounds.Y0, int(math.Floor(p[1]))), max(report.Bounds.Y1, int(math.Ceil(p[1])))
+			ft.Polygon[k] = [2]float64{math.Round(p[0]*100) / 100, math.Round(p[1]*100) / 100}
+		}
+
+		report.Tiles = append(report.Tiles, ft)
+	}
+
+	// the uv map can reach a fraction of a pixel past the flat canvas
+	report.Bounds = gridgen.XY2D{X0: max(report.Bounds.X0, m.Flat.X0), Y0: max(report.Bounds.Y0, m.Flat.Y0),
+		X1: min(report.Bounds.X1, m.Flat.X1), Y1: min(report.Bounds.Y1, m.Flat.Y1)}
+	if len(report.Tiles) == 0 {
+		report.Bounds = gridgen.XY2D{}
+	}
+
+	// trace the edges of the view clockwise, from the top left
+	report.Outline = [][2]float64{}
+	corners := [5][2]float64{{-tx, ty}, {tx, ty}, {tx, -ty}, {-tx, -ty}, {-tx, ty}}
+	for e := 0; e < 4; e++ {
+		for s := 0; s < outlineSteps; s++ {
+			f := float64(s) / outlineSteps
+			ray := [3]float64{corners[e][0] + f*(corners[e+1][0]-corners[e][0]), corners[e][1] + f*(corners[e+1][1]-corners[e][1]), 1}
+
+			if uv, ok := nearestHit(quads, ray); ok {
+				p := m.flatPixel(uv)
+				report.Outline = append(report.Outline, [2]float64{math.Round(p[0]*100) / 100, math.Round(p[1]*100) / 100})
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// nearestHit returns the uv map where a ray from the camera
+// first lands on a tile, in the coordinates of the camera.
+func nearestHit(quads [][4]previewVertex, ray [3]float64) ([2]float64, bool) {
+
+	best, uv, hit := math.Inf(1), [2]float64{}, false
+	for _, q := range quads {
+		for _, tri := range [2][3]int{{0, 1, 2}, {0, 2, 3}} {
+			a, b, c := q[tri[0]], q[tri[1]], q[tri[2]]
+
+			// intersect the ray with the triangle by its barycentric weights
+			e1, e2 := sub(b.p, a.p), sub(c.p, a.p)
+			h := cross(ray, e2)
+			det := dot(e1, h)
+			if math.Abs(det) < 1e-12 {
+				continue
+			}
+
+			s := [3]float64{-a.p[0], -a.p[1], -a.p[2]}
+			w1 := dot(s, h) / det
+			qv := cross(s, e1)
+			w2 := dot(ray, qv) / det
+			t := dot(e2, qv) / det
+			if w1 < 0 || w2 < 0 || w1+w2 > 1 || t <= previewNear || t >= best {
+				continue
+			}
+
+			best, hit = t, true
+			w0 := 1 - w1 - w2
+			uv = [2]float64{w0*a.uv[0] + w1*b.uv[0] + w2*c.uv[0], w0*a.uv[1] + w1*b.uv[1] + w2*c.uv[1]}
+		}
+	}
+
+	return uv, hit
+}
+
+// flatPixel returns the pixel of the flat canvas at a point of the uv map
+func (m *Model) flatPixel(uv [2]float64) [2]float64 {
+	return [2]float64{float64(m.Flat.X0) + uv[0]*float64(m.Flat.X1-m.Flat.X0), float64(m.Flat.Y0) + (1-uv[1])*float64(m.Flat.Y1-m.Flat.Y0)}
+}
+
+// polygonArea returns the area of a polygon
+func polygonArea(poly [][2]float64) float64 {
+
+	area := 0.0
+	for i, a := range poly {
+		b := poly[(i+1)%len(poly)]
+		area += a[0]*b[1] - b[0]*a[1]
+	}
+
+	return math.Abs(area) / 2
+}
+
+/*
+frustumMask draws the tiles in the frustum as a mask of the flat canvas,
+white inside the frustum and black outside. Pixels are inside the frustum
+when their centre is.
+*/
+func frustumMask(canvas gridgen.XY2D, tiles []frustumTile) *image.Gray {
+
+	mask := image.NewGray(image.Rect(0, 0, canvas.X1-canvas.X0, canvas.Y1-canvas.Y0))
+	width, height := mask.Rect.Dx(), mask.Rect.Dy()
+
+	for _, t := range tiles {
+		top, bottom := math.Inf(1), math.Inf(-1)
+		for _, p := range t.Polygon {
+			top, bottom = math.Min(top, p[1]-float64(canvas.Y0)), math.Max(bottom, p[1]-float64(canvas.Y0))
+		}
+
+		// fill each row between the edges it crosses, the
+		// clipped tiles are convex so a row crosses two edges
+		for y := max(int(math.Floor(top)), 0); y < min(int(math.Ceil(bottom)), height); y++ {
+			py := float64(y) + 0.5
+			left, right := math.Inf(1), math.Inf(-1)
+			for i, a := range t.Polygon {
+				b := t.Polygon[(i+1)%len(t.Polygon)]
+				ay, by := a[1]-float64(canvas.Y0), b[1]-float64(canvas.Y0)
+				if (ay > py) == (by > py) {
+					continue
+				}
+
+				x := a[0] + (py-ay)/(by-ay)*(b[0]-a[0]) - float64(canvas.X0)
+				left, right = math.Min(left, x), math.Max(right, x)
+			}
+
+			for x := max(int(math.Ceil(left-0.5)), 0); x < min(int(math.Ceil(right-0.5)), width); x++ {
+				mask.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+
+	return mask
+}
@@ -0,0 +1,74 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mrmxf/opentsg-modules/opentsg-core/gridgen"
+)
+
+func TestFrustumRegion(t *testing.T) {
+
+	// a full frame camera with a 35mm lens sees 36/35 across and 24/35 up, at a distance of 1
+	camera := func(eye, target [3]float64) frustumCamera {
+		return frustumCamera{view: previewCamera{eye: eye, target: target, up: [3]float64{0, 0, 1}}, focalLength: 35, sensor: [2]float64{36, 24}}
+	}
+
+	for _, tc := range []struct {
+		name   string
+		camera frustumCamera
+		tiles  int
+		bounds gridgen.XY2D
+		// full are tiles that are wholly in the frustum
+		full []string
+		// topLeft is where the outline starts, if the view lands on the wall
+		topLeft []float64
+	}{
+		{name: "middle of the wall", camera: camera([3]float64{3, -2, 1.5}, [3]float64{3, 0, 1.5}),
+			tiles: 12, bounds: gridgen.XY2D{X0: 197, Y0: 81, X1: 403, Y1: 219}, full: []string{"flatwall/r1c2"},
+			topLeft: []float64{300 - 200*36.0/70, 150 - 200*24.0/70}},
+		{name: "the whole wall", camera: camera([3]float64{3, -20, 1.5}, [3]float64{3, 0, 1.5}),
+			tiles: 18, bounds: gridgen.XY2D{X1: 600, Y1: 300}, full: []string{"flatwall/r0c0", "flatwall/r2c5"}},
+		{name: "looking away", camera: camera([3]float64{3, -2, 1.5}, [3]float64{3, -4, 1.5})},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := FlatWall{TileHeight: 1, TileWidth: 1, WallWidth: 6, WallHeight: 3, Dx: 100, Dy: 100}.Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			report, err := frustumRegion(m, tc.camera)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(report.Tiles) != tc.tiles || report.Bounds != tc.bounds {
+				t.Errorf("got %v tiles in %v, want %v in %v", len(report.Tiles), report.Bounds, tc.tiles, tc.bounds)
+			}
+
+			coverage := map[string]float64{}
+			for _, ft := range report.Tiles {
+				coverage[ft.Name] = ft.Coverage
+			}
+			for _, name := range tc.full {
+				if math.Abs(coverage[name]-1) > 1e-6 {
+					t.Errorf("%s has a coverage of %v, want 1", name, coverage[name])
+				}
+			}
+
+			if tc.topLeft != nil {
+				if len(report.Outline) != 4*outlineSteps {
+					t.Fatalf("the outline has %v points, want %v", len(report.Outline), 4*outlineSteps)
+				}
+				if p := report.Outline[0]; math.Abs(p[0]-tc.topLeft[0]) > 0.01 || math.Abs(p[1]-tc.topLeft[1]) > 0.01 {
+					t.Errorf("the outline starts at %v, want %v", p, tc.topLeft)
+				}
+			}
+		})
+	}
+}
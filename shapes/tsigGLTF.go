@@ -0,0 +1,296 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+// the mesh formats that can be written
+const (
+	MeshFormatOBJ  = "obj"
+	MeshFormatGLTF = "gltf"
+	MeshFormatGLB  = "glb"
+)
+
+// meshFormatFence checks the mesh format is one that can be written
+func meshFormatFence(format string) error {
+	switch format {
+	case MeshFormatOBJ, MeshFormatGLTF, MeshFormatGLB:
+		return nil
+	default:
+		return fmt.Errorf("unknown mesh format %q, the mesh format must be %q, %q or %q", format, MeshFormatOBJ, MeshFormatGLTF, MeshFormatGLB)
+	}
+}
+
+// the parts of a glTF 2.0 file that are written
+type gltfDoc struct {
+	Asset       gltfAsset        `json:"asset"`
+	Scene       int              `json:"scene"`
+	Scenes      []gltfScene      `json:"scenes"`
+	Nodes       []gltfNode       `json:"nodes"`
+	Meshes      []gltfMesh       `json:"meshes"`
+	Materials   []gltfMaterial   `json:"materials"`
+	Textures    []gltfTexture    `json:"textures,omitempty"`
+	Images      []gltfImage      `json:"images,omitempty"`
+	Accessors   []gltfAccessor   `json:"accessors"`
+	BufferViews []gltfBufferView `json:"bufferViews"`
+	Buffers     []gltfBuffer     `json:"buffers"`
+}
+
+type gltfAsset struct {
+	Version   string `json:"version"`
+	Generator string `json:"generator"`
+}
+
+type gltfScene struct {
+	Name  string `json:"name"`
+	Nodes []int  `json:"nodes"`
+}
+
+type gltfNode struct {
+	Name     string         `json:"name"`
+	Mesh     *int           `json:"mesh,omitempty"`
+	Children []int          `json:"children,omitempty"`
+	Extras   map[string]any `json:"extras,omitempty"`
+}
+
+type gltfMesh struct {
+	Name       string          `json:"name"`
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    int            `json:"indices"`
+	Material   int            `json:"material"`
+}
+
+type gltfMaterial struct {
+	Name                 string  `json:"name"`
+	PbrMetallicRoughness gltfPBR `json:"pbrMetallicRoughness"`
+	DoubleSided          bool    `json:"doubleSided"`
+}
+
+type gltfPBR struct {
+	BaseColorTexture *gltfTextureInfo `json:"baseColorTexture,omitempty"`
+	MetallicFactor   float64          `json:"metallicFactor"`
+	RoughnessFactor  float64          `json:"roughnessFactor"`
+}
+
+type gltfTextureInfo struct {
+	Index int `json:"index"`
+}
+
+type gltfTexture struct {
+	Source int `json:"source"`
+}
+
+type gltfImage struct {
+	URI string `json:"uri"`
+}
+
+type gltfAccessor struct {
+	BufferView    int       `json:"bufferView"`
+	ByteOffset    int       `json:"byteOffset"`
+	ComponentType int       `json:"componentType"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float64 `json:"min,omitempty"`
+	Max           []float64 `json:"max,omitempty"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride,omitempty"`
+	Target     int `json:"target"`
+}
+
+type gltfBuffer struct {
+	URI        string `json:"uri,omitempty"`
+	ByteLength int    `json:"byteLength"`
+}
+
+// glTF constants
+const (
+	gltfFloat        = 5126
+	gltfUnsignedInt  = 5125
+	gltfArrayBuffer  = 34962
+	gltfElementArray = 34963
+	glbMagic         = 0x46546C67
+	glbJSON          = 0x4E4F534A
+	glbBIN           = 0x004E4942
+)
+
+/*
+writeGLTF writes the model as a glTF 2.0 file, or as a binary GLB file if glb is true.
+
+Every tile is a named node, with its own mesh of two triangles, under a root node named
+after the shape. The TSIG tile name is kept in the extras of the node as "tsigName".
+
+The obj is z up, so the coordinates are rotated to the y up of glTF, unless the
+model has been transformed to y up already,
+and the v of the uv map is flipped as glTF textures start at the top.
+glTF is in metres, so models with units are scaled to metres.
+If a texture is given it is used as the base colour of every tile.
+*/
+func writeGLTF(w io.Writer, m *Model, texture string, glb bool) error {
+
+	// each attribute has its own buffer view, so the
+	// accessors of the tiles are offsets into them
+	var positions, normals, uvs, indices bytes.Buffer
+	doc := gltfDoc{Asset: gltfAsset{Version: "2.0", Generator: "tsig"}}
+
+	// z up to y up, unless the model has been turned already
+	yUp := func(p [3]float64) [3]float64 {
+		if m.UpAxis == UpAxisY {
+			return p
+		}
+		return [3]float64{p[0], p[2], -p[1]}
+	}
+
+	// the geometry is in millimetres if there are units
+	scale := 1.0
+	if _, ok := unitLengths[m.Units]; ok {
+		scale = 1.0 / 1000
+	}
+
+	write := func(buf *bytes.Buffer, values ...float64) {
+		for _, v := range values {
+			binary.Write(buf, binary.LittleEndian, float32(v))
+		}
+	}
+
+	root := gltfNode{Name: m.Shape}
+	for _, t := range m.Tiles {
+
+		// the corners in the order they are wound
+		order := [4]int{0, 1, 2, 3}
+		if t.Flip {
+			order = [4]int{0, 3, 2, 1}
+		}
+
+		minP := []float64{math.Inf(1), math.Inf(1), math.Inf(1)}
+		maxP := []float64{math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+
+		posOffset, normOffset, uvOffset, indOffset := positions.Len(), normals.Len(), uvs.Len(), indices.Len()
+		tileNormals := cornerNormals(t)
+		for _, o := range order {
+			p := yUp(t.Corners[o])
+			for j := range p {
+				p[j] *= scale
+			}
+			// glTF stores the float32 values, so the bounds are of those
+			for j := range p {
+				p[j] = float64(float32(p[j]))
+				minP[j] = math.Min(minP[j], p[j])
+				maxP[j] = math.Max(maxP[j], p[j])
+			}
+			write(&positions, p[:]...)
+
+			n := yUp(tileNormals[o])
+			write(&normals, n[:]...)
+
+			write(&uvs, t.UVs[o][0], 1-t.UVs[o][1])
+		}
+
+		binary.Write(&indices, binary.LittleEndian, []uint32{0, 1, 2, 0, 2, 3})
+
+		acc := len(doc.Accessors)
+		doc.Accessors = append(doc.Accessors,
+			gltfAccessor{BufferView: 0, ByteOffset: posOffset, ComponentType: gltfFloat, Count: 4, Type: "VEC3", Min: minP, Max: maxP},
+			gltfAccessor{BufferView: 1, ByteOffset: normOffset, ComponentType: gltfFloat, Count: 4, Type: "VEC3"},
+			gltfAccessor{BufferView: 2, ByteOffset: uvOffset, ComponentType: gltfFloat, Count: 4, Type: "VEC2"},
+			gltfAccessor{BufferView: 3, ByteOffset: indOffset, ComponentType: gltfUnsignedInt, Count: 6, Type: "SCALAR"},
+		)
+
+		mesh := len(doc.Meshes)
+		doc.Meshes = append(doc.Meshes, gltfMesh{Name: t.Name, Primitives: []gltfPrimitive{{
+			Attributes: map[string]int{"POSITION": acc, "NORMAL": acc + 1, "TEXCOORD_0": acc + 2},
+			Indices:    acc + 3,
+			Material:   0,
+		}}})
+
+		root.Children = append(root.Children, len(doc.Nodes))
+		doc.Nodes = append(doc.Nodes, gltfNode{Name: t.Name, Mesh: &mesh, Extras: map[string]any{"tsigName": t.Name}})
+	}
+
+	doc.Scenes = []gltfScene{{Name: m.Shape, Nodes: []int{len(doc.Nodes)}}}
+	doc.Nodes = append(doc.Nodes, root)
+
+	material := gltfMaterial{Name: materialName, PbrMetallicRoughness: gltfPBR{MetallicFactor: 0, RoughnessFactor: 1}}
+	if texture != "" {
+		doc.Images = []gltfImage{{URI: texture}}
+		doc.Textures = []gltfTexture{{Source: 0}}
+		material.PbrMetallicRoughness.BaseColorTexture = &gltfTextureInfo{Index: 0}
+	}
+	doc.Materials = []gltfMaterial{material}
+
+	// join the buffers, which are all multiples of 4 bytes
+	offset := 0
+	var bin bytes.Buffer
+	for i, b := range []*bytes.Buffer{&positions, &normals, &uvs, &indices} {
+		target := gltfArrayBuffer
+		if i == 3 {
+			target = gltfElementArray
+		}
+
+		doc.BufferViews = append(doc.BufferViews, gltfBufferView{Buffer: 0, ByteOffset: offset, ByteLength: b.Len(), Target: target})
+		offset += b.Len()
+		bin.Write(b.Bytes())
+	}
+
+	buffer := gltfBuffer{ByteLength: bin.Len()}
+	if !glb {
+		buffer.URI = "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(bin.Bytes())
+	}
+	doc.Buffers = []gltfBuffer{buffer}
+
+	if !glb {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "    ")
+		if err := enc.Encode(doc); err != nil {
+			return fmt.Errorf("error writing to gltf %v", err)
+		}
+
+		return nil
+	}
+
+	jsonChunk, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	// chunks are padded to 4 bytes, json with spaces and the binary with zeros
+	for len(jsonChunk)%4 != 0 {
+		jsonChunk = append(jsonChunk, ' ')
+	}
+	for bin.Len()%4 != 0 {
+		bin.WriteByte(0)
+	}
+
+	var glbBuf bytes.Buffer
+	header := []uint32{glbMagic, 2, uint32(12 + 8 + len(jsonChunk) + 8 + bin.Len())}
+	binary.Write(&glbBuf, binary.LittleEndian, header)
+	binary.Write(&glbBuf, binary.LittleEndian, []uint32{uint32(len(jsonChunk)), glbJSON})
+	glbBuf.Write(jsonChunk)
+	binary.Write(&glbBuf, binary.LittleEndian, []uint32{uint32(bin.Len()), glbBIN})
+	glbBuf.Write(bin.Bytes())
+
+	if _, err := w.Write(glbBuf.Bytes()); err != nil {
+		return fmt.Errorf("error writing to glb %v", err)
+	}
+
+	return nil
+}
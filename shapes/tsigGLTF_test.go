@@ -0,0 +1,135 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// readGLTF reads the json and binary buffer of a glTF or GLB file
+func readGLTF(t *testing.T, data []byte, glb bool) (gltfDoc, []byte) {
+	t.Helper()
+
+	var doc gltfDoc
+	if !glb {
+		if err := json.Unmarshal(data, &doc); err != nil {
+			t.Fatal(err)
+		}
+
+		encoded, ok := strings.CutPrefix(doc.Buffers[0].URI, "data:application/octet-stream;base64,")
+		if !ok {
+			t.Fatalf("the buffer is not embedded, its uri is %.40s", doc.Buffers[0].URI)
+		}
+
+		bin, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return doc, bin
+	}
+
+	var header [5]uint32
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, &header); err != nil {
+		t.Fatal(err)
+	}
+
+	if header[0] != glbMagic || header[1] != 2 || int(header[2]) != len(data) || header[4] != glbJSON {
+		t.Fatalf("the glb header is %x", header)
+	}
+
+	jsonEnd := 20 + int(header[3])
+	if err := json.Unmarshal(data[20:jsonEnd], &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	var binHeader [2]uint32
+	if err := binary.Read(bytes.NewReader(data[jsonEnd:]), binary.LittleEndian, &binHeader); err != nil {
+		t.Fatal(err)
+	}
+
+	if binHeader[1] != glbBIN || jsonEnd+8+int(binHeader[0]) != len(data) {
+		t.Fatalf("the glb binary chunk header is %x", binHeader)
+	}
+
+	return doc, data[jsonEnd+8:]
+}
+
+func TestWriteGLTF(t *testing.T) {
+
+	for _, tc := range []struct {
+		name    string
+		glb     bool
+		texture string
+		change  func(m *Model)
+		// the bounds of the first tile, and the uv of its first corner
+		min, max []float64
+		uv       [2]float32
+	}{
+		{name: "gltf", change: func(m *Model) {},
+			min: []float64{0, 0, 0}, max: []float64{1, 1, 0}, uv: [2]float32{0, 1}},
+		{name: "glb", glb: true, change: func(m *Model) {},
+			min: []float64{0, 0, 0}, max: []float64{1, 1, 0}, uv: [2]float32{0, 1}},
+		{name: "texture", texture: "pattern.png", change: func(m *Model) {},
+			min: []float64{0, 0, 0}, max: []float64{1, 1, 0}, uv: [2]float32{0, 1}},
+		{name: "millimetres in metres", glb: true, change: func(m *Model) { m.Units = "mm" },
+			min: []float64{0, 0, 0}, max: []float64{float64(float32(0.001)), float64(float32(0.001)), 0}, uv: [2]float32{0, 1}},
+		{name: "already y up", change: func(m *Model) { m.UpAxis = UpAxisY },
+			min: []float64{0, 0, 0}, max: []float64{1, 0, 1}, uv: [2]float32{0, 1}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := testWall(t, 3, 2)
+			tc.change(m)
+
+			var out bytes.Buffer
+			if err := writeGLTF(&out, m, tc.texture, tc.glb); err != nil {
+				t.Fatal(err)
+			}
+
+			doc, bin := readGLTF(t, out.Bytes(), tc.glb)
+
+			// a node for each tile, under a root node of the shape
+			root := doc.Nodes[doc.Scenes[doc.Scene].Nodes[0]]
+			if root.Name != m.Shape || len(root.Children) != len(m.Tiles) {
+				t.Fatalf("the root node is %s with %v children, want %s with %v", root.Name, len(root.Children), m.Shape, len(m.Tiles))
+			}
+
+			for i, child := range root.Children {
+				node := doc.Nodes[child]
+				if node.Name != m.Tiles[i].Name || node.Extras["tsigName"] != m.Tiles[i].Name || node.Mesh == nil {
+					t.Errorf("node %v is %s of %v with a mesh of %v, want %s", i, node.Name, node.Extras, node.Mesh, m.Tiles[i].Name)
+				}
+			}
+
+			mesh := doc.Meshes[*doc.Nodes[root.Children[0]].Mesh].Primitives[0]
+			position := doc.Accessors[mesh.Attributes["POSITION"]]
+			if !slices.Equal(position.Min, tc.min) || !slices.Equal(position.Max, tc.max) {
+				t.Errorf("the first tile is from %v to %v, want %v to %v", position.Min, position.Max, tc.min, tc.max)
+			}
+
+			uvs := doc.Accessors[mesh.Attributes["TEXCOORD_0"]]
+			var uv [2]float32
+			offset := doc.BufferViews[uvs.BufferView].ByteOffset + uvs.ByteOffset
+			if err := binary.Read(bytes.NewReader(bin[offset:]), binary.LittleEndian, &uv); err != nil {
+				t.Fatal(err)
+			}
+			if uv != tc.uv {
+				t.Errorf("the uv of the first corner is %v, want %v", uv, tc.uv)
+			}
+
+			textured := doc.Materials[0].PbrMetallicRoughness.BaseColorTexture != nil
+			if textured != (tc.texture != "") || (textured && doc.Images[0].URI != tc.texture) {
+				t.Errorf("the material has a texture of %v and images of %v, want %q", textured, doc.Images, tc.texture)
+			}
+		})
+	}
+}
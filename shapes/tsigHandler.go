@@ -18,14 +18,24 @@ func init() {
 	// assign all the cmd functions
 	cmdBoth.Flags().StringVar(&configFile, "conf", "", "The configuration file")
 	cmdBoth.Flags().StringVar(&outFile, "outputFile", "./output", "The name of the output file")
+	cmdBoth.Flags().StringVar(&textureFile, "texture", "", "The test pattern image to texture the mesh with, a .mtl file is written alongside an obj")
+	cmdBoth.Flags().StringVar(&meshFormat, "meshFormat", MeshFormatOBJ, "The format of the mesh, either obj, gltf or glb")
+	cmdBoth.Flags().StringVar(&catalogFile, "catalog", "", "A tile catalog file, with tiles that are added to the bundled catalog")
 
 	cmdObj.Flags().StringVar(&configFile, "conf", "", "The configuration file")
 	cmdObj.Flags().StringVar(&outFile, "outputFile", "./output", "The name of the output file")
+	cmdObj.Flags().StringVar(&textureFile, "texture", "", "The test pattern image to texture the mesh with, a .mtl file is written alongside an obj")
+	cmdObj.Flags().StringVar(&meshFormat, "meshFormat", MeshFormatOBJ, "The format of the mesh, either obj, gltf or glb")
+	cmdObj.Flags().StringVar(&catalogFile, "catalog", "", "A tile catalog file, with tiles that are added to the bundled catalog")
 
 	cmdTSIG.Flags().StringVar(&configFile, "conf", "", "The configuration file")
 	cmdTSIG.Flags().StringVar(&outFile, "outputFile", "./output", "The name of the output file")
+	cmdTSIG.Flags().StringVar(&catalogFile, "catalog", "", "A tile catalog file, with tiles that are added to the bundled catalog")
 
-	cmdBoth.AddCommand(cmdObj, cmdTSIG, cmdList)
+	cmdTilesList.Flags().StringVar(&catalogFile, "catalog", "", "A tile catalog file, with tiles that are added to the bundled catalog")
+	cmdTiles.AddCommand(cmdTilesList)
+
+	cmdBoth.AddCommand(cmdObj, cmdTSIG, cmdList, cmdTiles)
 }
 
 // shapes is a map of shapeName - yaml decoder to that type
@@ -69,6 +79,17 @@ type ShapeName struct {
 	Shape string `json:"shape" yaml:"shape"`
 }
 
+// planConfig is the configuration of the planners,
+// that can be used with any shape.
+type planConfig struct {
+	Carve  *CarveConfig  `json:"carve" yaml:"carve"`
+	Wiring *WiringConfig `json:"wiring" yaml:"wiring"`
+	// Transform moves the output of the shape
+	Transform *TransformConfig `json:"transform" yaml:"transform"`
+	// the bezel and seam gap are top level fields
+	Seams SeamConfig `json:",inline" yaml:",inline"`
+}
+
 // RunHandler runs the CLI functionality
 func RunHandler() error {
 	err := cmdBoth.Execute()
@@ -134,9 +155,52 @@ var cmdList = &cobra.Command{
 	},
 }
 
+var cmdTiles = &cobra.Command{
+	Use:   "tiles",
+	Short: "the tile catalog",
+	Long: `
+	The tile catalog of tile products, that can be used
+	with the tile field of a configuration
+	`,
+}
+
+var cmdTilesList = &cobra.Command{
+	Use:   "list",
+	Short: "list all the tiles in the tile catalog",
+	Long: `
+	List all the tiles in the bundled tile catalog and the catalog file, if one is given.
+	Sizes are in mm and weights in kg.
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		tiles, err := loadCatalog(catalogFile)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("Available tiles are:")
+		for _, t := range tiles {
+			fmt.Printf(" - %v: %v \n", t.Name, t.Description)
+			fmt.Printf("   %vx%vmm, %vx%v pixels, %.3gmm pitch", t.Width, t.Height, t.PixelsX, t.PixelsY, t.Width/float64(t.PixelsX))
+			if t.Bezel != 0 {
+				fmt.Printf(", %vmm bezel", t.Bezel)
+			}
+			if t.Weight != 0 {
+				fmt.Printf(", %vkg", t.Weight)
+			}
+			fmt.Println()
+		}
+
+		return nil
+	},
+}
+
 var (
-	configFile = ""
-	outFile    = ""
+	configFile  = ""
+	outFile     = ""
+	textureFile = ""
+	meshFormat  = MeshFormatOBJ
+	catalogFile = ""
 )
 
 // Generator is for writing shapes
@@ -152,6 +216,24 @@ func genShapeNew(tsig, obj bool) func(cmd *cobra.Command, args []string) error {
 	return func(cmd *cobra.Command, args []string) error {
 		confBytes, err := os.ReadFile(configFile)
 
+		if err != nil {
+			return err
+		}
+
+		// set the tile from the catalog, before the shape is read
+		catalog, err := loadCatalog(catalogFile)
+		if err != nil {
+			return err
+		}
+
+		confBytes, err = applyCatalog(confBytes, catalog)
+		if err != nil {
+			return err
+		}
+
+		// convert the lengths to millimetres and set the pixels
+		// per tile from the pixel pitch, before the shape is read
+		confBytes, units, unitNotes, err := applyUnits(confBytes)
 		if err != nil {
 			return err
 		}
@@ -176,30 +258,94 @@ func genShapeNew(tsig, obj bool) func(cmd *cobra.Command, args []string) error {
 			return err
 		}
 
-		var fObj io.Writer
-		if obj {
-			fObj, err = os.Create(outFile + ".obj")
+		if err := meshFormatFence(meshFormat); err != nil {
+			return err
+		}
+
+		model, err := buildModel(shp)
+		if err != nil {
+			return err
+		}
+		model.Units = units.Units
+		model.Notes = append(unitNotes, model.Notes...)
+
+		// plan the layouts that are shared by every shape
+		var plans planConfig
+		err = yaml.Unmarshal(confBytes, &plans)
+		if err != nil {
+			return err
+		}
+
+		// shapes made of parts have seamed each part as it was built
+		if _, ok := shp.(seamedParts); !ok {
+			err = applySeams(model, plans.Seams)
 			if err != nil {
 				return err
 			}
-		} else {
-			fObj = io.Discard
 		}
 
-		var fTSIG io.Writer
+		if plans.Transform != nil {
+			if err := transformFence(*plans.Transform, meshFormat); err != nil {
+				return err
+			}
 
-		if tsig {
-			fTSIG, err = os.Create(outFile + ".json")
+			err = applyTransform(model, *plans.Transform)
 			if err != nil {
 				return err
 			}
-		} else {
-			fTSIG = io.Discard
 		}
 
-		err = shp.Generate(fObj, fTSIG)
-		if err != nil {
-			return err
+		if plans.Carve != nil {
+			err = planCarve(model, *plans.Carve)
+			if err != nil {
+				return err
+			}
+		}
+
+		var chains []wiringChain
+		if plans.Wiring != nil {
+			chains, err = planWiring(model, *plans.Wiring)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, note := range model.Notes {
+			fmt.Println(note)
+		}
+
+		if obj {
+			err = writeMesh(model)
+			if err != nil {
+				return err
+			}
+		}
+
+		if tsig {
+			fTSIG, err := os.Create(outFile + ".json")
+			if err != nil {
+				return err
+			}
+			defer fTSIG.Close()
+
+			err = writeTSIG(fTSIG, model)
+			if err != nil {
+				return err
+			}
+
+			// the wiring diagram goes with the TSIG
+			if plans.Wiring != nil {
+				fSVG, err := os.Create(outFile + ".svg")
+				if err != nil {
+					return err
+				}
+				defer fSVG.Close()
+
+				err = writeWiringSVG(fSVG, model, chains)
+				if err != nil {
+					return err
+				}
+			}
 		}
 
 		fmt.Printf("Generated %v object\n", shp.ObjType())
@@ -219,3 +365,34 @@ func unmarshalGenerator[gen Generator](bytes []byte) (Generator, error) {
 	}
 	return *out, nil
 }
+
+// writeMesh writes the model as the mesh format to the output file,
+// with the texture if one is given.
+func writeMesh(model *Model) error {
+
+	meshFile := outFile + "." + meshFormat
+	fMesh, err := os.Create(meshFile)
+	if err != nil {
+		return err
+	}
+	defer fMesh.Close()
+
+	if meshFormat != MeshFormatOBJ {
+		texture := ""
+		if textureFile != "" {
+			texture = relativePath(meshFile, textureFile)
+		}
+
+		return writeGLTF(fMesh, model, texture, meshFormat == MeshFormatGLB)
+	}
+
+	// add the material before any of the shape
+	if textureFile != "" {
+		err = writeMaterial(fMesh, outFile, textureFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeOBJ(fMesh, model)
+}
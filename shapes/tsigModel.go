@@ -0,0 +1,412 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/mrmxf/opentsg-modules/opentsg-core/gridgen"
+)
+
+// Model is the in memory geometry and flat layout of a shape.
+// The obj, TSIG and any other outputs are all written from the model.
+type Model struct {
+	// the shape the model is of, e.g. cube
+	Shape string
+	Tiles []ModelTile
+	// Flat is the size of the flat canvas in pixels
+	Flat gridgen.XY2D
+	// Carve is the size of all the carved outputs, and Outputs
+	// is the region of each carved output, by name.
+	// They are empty if the model has not been carved.
+	Carve   gridgen.XY2D
+	Outputs map[string]gridgen.XY2D
+	// Units are the units the geometry is written in, e.g. m.
+	// The geometry is in millimetres when they are given,
+	// and they are empty if the units are abstract.
+	Units string
+	// UpAxis and Handedness are the coordinates of the geometry,
+	// they are empty for the z up, right handed coordinates of the shapes.
+	UpAxis     string
+	Handedness string
+	// Notes are any information about the model for the user,
+	// e.g. the gap left in a closed ring. They are written as
+	// comments at the top of the obj.
+	Notes []string
+}
+
+// ModelTile is a tile of a model, or a strip of the tile
+// if it has been split into strips.
+type ModelTile struct {
+	// the TSIG name, tags and neighbours of the tile
+	Name       string
+	Tags       []string
+	Neighbours []string
+	// the face of the shape the tile is on, can be empty
+	Face string
+	// the row and column of the tile on its face,
+	// rows are counted from the bottom and columns from the left
+	Row, Col int
+	// Strip is the strip number, counting from 1, of a tile that
+	// has been split into strips. It is 0 for whole tiles
+	Strip int
+	// the 3D corners of the tile, anticlockwise around the face
+	// unless the tile is flipped.
+	Corners [4][3]float64
+	// the uv map coordinates of each corner
+	UVs [4][2]float64
+	// the analytic normal of each corner, for curved surfaces.
+	// The face normal is used when they are not set.
+	Normals [4][3]float64
+	// Flip reverses the winding of the corners when the face is written
+	Flip bool
+	// Flat is the top left pixel of the tile on the flat canvas
+	// and Size is the pixel size of the tile.
+	Flat gridgen.XY
+	Size gridgen.XY
+	// Carve is the top left pixel of the tile in the carved
+	// outputs, with the name of the output as the destination
+	Carve gridgen.XY
+}
+
+/*
+ModelBuilder is a Generator that builds an in memory model of the shape,
+which the obj, TSIG and other formats are written from.
+
+The Generate method of a ModelBuilder is expected to write the model, e.g.
+
+	func (s Shape) Generate(wObj, wTsig io.Writer) error {
+		return generate(s, wObj, wTsig)
+	}
+*/
+type ModelBuilder interface {
+	Generator
+	// Build the model of the shape
+	Build() (*Model, error)
+}
+
+// generate builds the model and writes it as an obj and TSIG.
+func generate(b ModelBuilder, wObj, wTsig io.Writer) error {
+	m, err := b.Build()
+	if err != nil {
+		return err
+	}
+
+	if err := writeOBJ(wObj, m); err != nil {
+		return err
+	}
+
+	return writeTSIG(wTsig, m)
+}
+
+// TPIG returns the TSIG of the model
+func (m *Model) TPIG() gridgen.TPIG {
+
+	tiles := make([]gridgen.Tilelayout, len(m.Tiles))
+	for i, t := range m.Tiles {
+		tiles[i] = gridgen.Tilelayout{Name: t.Name, Tags: t.Tags, Neighbours: t.Neighbours,
+			Layout: gridgen.Positions{Carve: t.Carve, Flat: t.Flat, Size: t.Size}}
+	}
+
+	return gridgen.TPIG{Tilelayout: tiles, Dimensions: gridgen.Dimensions{Carve: m.Carve, Flat: m.Flat}, Carve: m.Outputs}
+}
+
+// writeTSIG writes the model as a TSIG json,
+// with the units of the model if they are known.
+func writeTSIG(w io.Writer, m *Model) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+
+	return enc.Encode(struct {
+		gridgen.TPIG
+		Units string `json:"Units,omitempty"`
+	}{TPIG: m.TPIG(), Units: m.Units})
+}
+
+/*
+buildModel returns the model of a generator.
+
+Generators that are not ModelBuilders are adapted, by generating their
+obj and TSIG and reading the model back from them.
+*/
+func buildModel(g Generator) (*Model, error) {
+	if b, ok := g.(ModelBuilder); ok {
+		return b.Build()
+	}
+
+	var obj, tsig bytes.Buffer
+	if err := g.Generate(&obj, &tsig); err != nil {
+		return nil, err
+	}
+
+	return modelFromOutput(g.ObjType(), &obj, &tsig)
+}
+
+// modelPart is a model placed in a merged model, on a face of the
+// merged model at a pixel offset of the flat canvas.
+type modelPart struct {
+	model *Model
+	face  string
+	at    gridgen.XY
+}
+
+/*
+mergeModels merges the parts into one model of the shape.
+
+The faces of each part are put on the face of the part, e.g. the back face of a
+part on the face main becomes main/back. The flat layout of each part is moved
+to its offset, and the uv maps are moved to match. The notes of the parts are kept,
+with their tiles named as they are in the merged model.
+*/
+func mergeModels(shape string, parts []modelPart) *Model {
+
+	m := &Model{Shape: shape}
+	for _, p := range parts {
+		m.Flat.X1 = max(m.Flat.X1, p.at.X+p.model.Flat.X1-p.model.Flat.X0)
+		m.Flat.Y1 = max(m.Flat.Y1, p.at.Y+p.model.Flat.Y1-p.model.Flat.Y0)
+	}
+
+	width, height := float64(m.Flat.X1), float64(m.Flat.Y1)
+	for _, p := range parts {
+		src := p.model
+		w, h := float64(src.Flat.X1-src.Flat.X0), float64(src.Flat.Y1-src.Flat.Y0)
+		x, y := float64(p.at.X), float64(p.at.Y)
+
+		for _, t := range src.Tiles {
+			if t.Face == "" {
+				t.Face = p.face
+			} else {
+				t.Face = p.face + "/" + t.Face
+			}
+
+			t.Flat.X += p.at.X - src.Flat.X0
+			t.Flat.Y += p.at.Y - src.Flat.Y0
+			for c, uv := range t.UVs {
+				t.UVs[c] = [2]float64{(uv[0]*w + x) / width, 1 - ((1-uv[1])*h+y)/height}
+			}
+
+			m.Tiles = append(m.Tiles, t)
+		}
+
+		for _, n := range src.Notes {
+			m.Notes = append(m.Notes, strings.ReplaceAll(n, src.Shape+"/", shape+"/"+p.face+"/"))
+		}
+	}
+
+	describeTiles(m)
+
+	return m
+}
+
+/*
+modelFromOutput makes a model from the obj and TSIG of a generator.
+
+The faces of the obj are matched to the TSIG tiles by the last name of their
+g statement. If the faces are not named after the tiles, then they are matched
+in order. Each face must be a triangle or a quad.
+
+The face, row, column and strip of each tile are read from the TSIG tags, if present.
+*/
+func modelFromOutput(shape string, obj, tsig io.Reader) (*Model, error) {
+
+	var tpig gridgen.TPIG
+	if err := json.NewDecoder(tsig).Decode(&tpig); err != nil {
+		return nil, fmt.Errorf("error reading the TSIG of %s: %v", shape, err)
+	}
+
+	faces, err := parseOBJ(obj)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the obj of %s: %v", shape, err)
+	}
+
+	if len(faces) != len(tpig.Tilelayout) {
+		return nil, fmt.Errorf("the obj of %s has %v faces and the TSIG has %v tiles, so they can not be matched", shape, len(faces), len(tpig.Tilelayout))
+	}
+
+	byName := map[string]int{}
+	for i, t := range tpig.Tilelayout {
+		byName[t.Name] = i
+	}
+
+	// match by name only if every face is named after a tile
+	named := true
+	for _, f := range faces {
+		if _, ok := byName[f.Name]; !ok {
+			named = false
+			break
+		}
+	}
+
+	m := &Model{Shape: shape, Tiles: make([]ModelTile, len(faces)), Flat: tpig.Dimensions.Flat, Carve: tpig.Dimensions.Carve, Outputs: tpig.Carve}
+	for i, f := range faces {
+		t := tpig.Tilelayout[i]
+		if named {
+			t = tpig.Tilelayout[byName[f.Name]]
+		}
+
+		f.Name, f.Tags, f.Neighbours = t.Name, t.Tags, t.Neighbours
+		f.Flat, f.Size, f.Carve = t.Layout.Flat, t.Layout.Size, t.Layout.Carve
+		for _, tag := range t.Tags {
+			key, value, _ := strings.Cut(tag, ":")
+			switch key {
+			case "face":
+				f.Face = value
+			case "row":
+				f.Row, _ = strconv.Atoi(value)
+			case "col":
+				f.Col, _ = strconv.Atoi(value)
+			case "strip":
+				f.Strip, _ = strconv.Atoi(value)
+			}
+		}
+
+		m.Tiles[i] = f
+	}
+
+	return m, nil
+}
+
+/*
+parseOBJ reads the faces of an obj as tiles. Each tile is named after the
+last name of its g statement, which is the TSIG tile name in the obj written
+by the shapes.
+
+Triangles are read as a quad with the last corner repeated.
+*/
+func parseOBJ(r io.Reader) ([]ModelTile, error) {
+
+	var positions, normals [][3]float64
+	var uvs [][2]float64
+
+	tiles := []ModelTile{}
+	group := ""
+
+	// index resolves an obj index, which can be relative from the end
+	index := func(field string, length int) (int, error) {
+		if field == "" {
+			return -1, nil
+		}
+
+		i, err := strconv.Atoi(field)
+		if err != nil {
+			return 0, err
+		}
+
+		if i < 0 {
+			i += length
+		} else {
+			i--
+		}
+
+		if i < 0 || i >= length {
+			return 0, fmt.Errorf("obj index %v is out of range", field)
+		}
+
+		return i, nil
+	}
+
+	// floats parses the coordinates of a line
+	floats := func(fields []string, count int) ([]float64, error) {
+		if len(fields) < count {
+			return nil, fmt.Errorf("%v coordinates are needed", count)
+		}
+
+		values := make([]float64, count)
+		for i := range values {
+			f, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = f
+		}
+
+		return values, nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "v", "vn":
+			p, err := floats(fields[1:], 3)
+			if err != nil {
+				return nil, fmt.Errorf("line %v: %v", line, err)
+			}
+
+			if fields[0] == "v" {
+				positions = append(positions, [3]float64{p[0], p[1], p[2]})
+			} else {
+				normals = append(normals, [3]float64{p[0], p[1], p[2]})
+			}
+		case "vt":
+			uv, err := floats(fields[1:], 2)
+			if err != nil {
+				return nil, fmt.Errorf("line %v: %v", line, err)
+			}
+			uvs = append(uvs, [2]float64{uv[0], uv[1]})
+		case "g":
+			group = fields[len(fields)-1]
+		case "f":
+			corners := fields[1:]
+			if len(corners) < 3 || len(corners) > 4 {
+				return nil, fmt.Errorf("line %v: only triangle and quad faces can be read, got %v vertexes", line, len(corners))
+			}
+
+			// repeat the last corner of triangles
+			if len(corners) == 3 {
+				corners = append(corners, corners[2])
+			}
+
+			t := ModelTile{Name: group}
+			for c, f := range corners {
+				parts := strings.Split(f, "/")
+				lengths := [3]int{len(positions), len(uvs), len(normals)}
+
+				var key [3]int
+				for i := range key {
+					key[i] = -1
+					if i < len(parts) {
+						k, err := index(parts[i], lengths[i])
+						if err != nil {
+							return nil, fmt.Errorf("line %v: %v", line, err)
+						}
+						key[i] = k
+					}
+				}
+
+				if key[0] < 0 {
+					return nil, fmt.Errorf("line %v: face vertex %q has no position", line, f)
+				}
+
+				t.Corners[c] = positions[key[0]]
+				if key[1] >= 0 {
+					t.UVs[c] = uvs[key[1]]
+				}
+				if key[2] >= 0 {
+					t.Normals[c] = normals[key[2]]
+				}
+			}
+
+			tiles = append(tiles, t)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return tiles, nil
+}
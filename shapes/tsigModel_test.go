@@ -0,0 +1,153 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+// testWall builds a flat wall of 1x1 tiles, of 10x10 pixels each
+func testWall(t *testing.T, columns, rows int) *Model {
+	t.Helper()
+
+	m, err := FlatWall{TileHeight: 1, TileWidth: 1, WallWidth: float64(columns), WallHeight: float64(rows), Dx: 10, Dy: 10}.Build()
+	if err != nil {
+		t.Fatalf("building a %vx%v wall: %v", columns, rows, err)
+	}
+
+	return m
+}
+
+// near checks two points are the same, within floating point error
+func near(a, b [3]float64) bool {
+	for k := range a {
+		if math.Abs(a[k]-b[k]) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestModelRoundTrip(t *testing.T) {
+
+	curve := func(t *testing.T) *Model {
+		m, err := Curve{TileHeight: 0.5, TileWidth: 0.5, CurveRadius: 5, CurveHeight: 1, AzimuthMaxAngle: 0.3, Dx: 50, Dy: 50}.Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	for _, tc := range []struct {
+		name  string
+		model func(t *testing.T) *Model
+	}{
+		{"flatwall", func(t *testing.T) *Model { return testWall(t, 3, 2) }},
+		{"curve", curve},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			want := tc.model(t)
+
+			var obj, tsig bytes.Buffer
+			if err := writeOBJ(&obj, want); err != nil {
+				t.Fatal(err)
+			}
+			if err := writeTSIG(&tsig, want); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := modelFromOutput(tc.name, &obj, &tsig)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Flat != want.Flat || len(got.Tiles) != len(want.Tiles) {
+				t.Fatalf("got a %v canvas of %v tiles, want a %v canvas of %v tiles", got.Flat, len(got.Tiles), want.Flat, len(want.Tiles))
+			}
+
+			for i, w := range want.Tiles {
+				g := got.Tiles[i]
+				if g.Name != w.Name || g.Flat != w.Flat || g.Size != w.Size || g.Row != w.Row || g.Col != w.Col {
+					t.Errorf("tile %v is %s at %v of %v row %v col %v, want %s at %v of %v row %v col %v",
+						i, g.Name, g.Flat, g.Size, g.Row, g.Col, w.Name, w.Flat, w.Size, w.Row, w.Col)
+				}
+
+				for c := range w.Corners {
+					if !near(g.Corners[c], w.Corners[c]) {
+						t.Errorf("%s corner %v is %v, want %v", w.Name, c, g.Corners[c], w.Corners[c])
+					}
+					if math.Abs(g.UVs[c][0]-w.UVs[c][0]) > 1e-9 || math.Abs(g.UVs[c][1]-w.UVs[c][1]) > 1e-9 {
+						t.Errorf("%s uv %v is %v, want %v", w.Name, c, g.UVs[c], w.UVs[c])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestParseOBJ(t *testing.T) {
+
+	square := [4][3]float64{{0, 0, 0}, {1, 0, 0}, {1, 0, 1}, {0, 0, 1}}
+
+	for _, tc := range []struct {
+		name    string
+		obj     string
+		corners [][4][3]float64
+		uvs     [][4][2]float64
+		err     string
+	}{
+		{name: "absolute indices",
+			obj:     "v 0 0 0\nv 1 0 0\nv 1 0 1\nv 0 0 1\nvt 0 0\nvt 1 0\nvt 1 1\nvt 0 1\ng wall wall/a\nf 1/1 2/2 3/3 4/4\n",
+			corners: [][4][3]float64{square},
+			uvs:     [][4][2]float64{{{0, 0}, {1, 0}, {1, 1}, {0, 1}}}},
+		{name: "relative indices",
+			obj:     "v 0 0 0\nv 1 0 0\nv 1 0 1\nv 0 0 1\nvt 0 0\nvt 1 0\nvt 1 1\nvt 0 1\ng wall/a\nf -4/-4 -3/-3 -2/-2 -1/-1\n",
+			corners: [][4][3]float64{square},
+			uvs:     [][4][2]float64{{{0, 0}, {1, 0}, {1, 1}, {0, 1}}}},
+		{name: "triangle",
+			obj:     "v 0 0 0\nv 1 0 0\nv 1 0 1\ng wall/a\nf 1 2 3\n",
+			corners: [][4][3]float64{{{0, 0, 0}, {1, 0, 0}, {1, 0, 1}, {1, 0, 1}}},
+			uvs:     [][4][2]float64{{}}},
+		{name: "index out of range",
+			obj: "v 0 0 0\nv 1 0 0\nv 1 0 1\ng wall/a\nf 1 2 4\n",
+			err: "out of range"},
+		{name: "pentagon",
+			obj: "v 0 0 0\nv 1 0 0\nv 1 0 1\nv 0 0 1\nv 0 0 2\ng wall/a\nf 1 2 3 4 5\n",
+			err: "only triangle and quad faces"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tiles, err := parseOBJ(strings.NewReader(tc.obj))
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("got the error %v, want an error of %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(tiles) != len(tc.corners) {
+				t.Fatalf("got %v tiles, want %v", len(tiles), len(tc.corners))
+			}
+
+			for i, tile := range tiles {
+				if tile.Name != "wall/a" {
+					t.Errorf("tile %v is named %q, want wall/a", i, tile.Name)
+				}
+				if tile.Corners != tc.corners[i] {
+					t.Errorf("tile %v has the corners %v, want %v", i, tile.Corners, tc.corners[i])
+				}
+				if tile.UVs != tc.uvs[i] {
+					t.Errorf("tile %v has the uvs %v, want %v", i, tile.UVs, tc.uvs[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// the name of the material every tile uses
+const materialName = "tsig"
+
+/*
+writeMaterial writes the .mtl file of outFile, with a material
+that uses the texture as its diffuse map. Then the mtllib and usemtl
+statements are written to the obj, so the obj opens already textured.
+
+The texture is referenced relative to the .mtl file where possible,
+so the files can be moved together.
+*/
+func writeMaterial(wObj io.Writer, outFile, texture string) error {
+
+	mtlFile := outFile + ".mtl"
+
+	// obj readers find the texture relative to the mtl
+	mapKd := relativePath(mtlFile, texture)
+
+	fMtl, err := os.Create(mtlFile)
+	if err != nil {
+		return err
+	}
+	defer fMtl.Close()
+
+	_, err = fmt.Fprintf(fMtl, "newmtl %s\nKa 1 1 1\nKd 1 1 1\nKs 0 0 0\nd 1\nillum 1\nmap_Kd %s\n", materialName, mapKd)
+	if err != nil {
+		return fmt.Errorf("error writing to mtl %v", err)
+	}
+
+	_, err = fmt.Fprintf(wObj, "mtllib %s\nusemtl %s\n", filepath.Base(mtlFile), materialName)
+	if err != nil {
+		return fmt.Errorf("error writing to obj %v", err)
+	}
+
+	return nil
+}
+
+// relativePath returns the path of target relative to the directory of file,
+// if target is a relative path. Otherwise target is returned as is.
+func relativePath(file, target string) string {
+	if filepath.IsAbs(target) {
+		return target
+	}
+
+	dir, errDir := filepath.Abs(filepath.Dir(file))
+	absTarget, errTarget := filepath.Abs(target)
+	if errDir != nil || errTarget != nil {
+		return target
+	}
+
+	rel, err := filepath.Rel(dir, absTarget)
+	if err != nil {
+		return target
+	}
+
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,145 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+)
+
+/*
+writeOBJ writes the model as an obj. Each tile is a face with
+vertexes, texture coordinates and normals. The units and any notes
+of the model are written as comments at the top of the obj, and the
+geometry is scaled from millimetres to the units, if they are given.
+
+The object is named after the shape, and every tile is in the groups of
+its face, its row on that face and its tile name. e.g.
+
+	g cube/back cube/back/r3 cube/back/r3c5
+*/
+func writeOBJ(w io.Writer, m *Model) error {
+
+	buf := bufio.NewWriter(w)
+	if m.Units != "" {
+		fmt.Fprintf(buf, "# units: %s\n", m.Units)
+	}
+	if m.UpAxis != "" {
+		fmt.Fprintf(buf, "# up axis: %s\n", m.UpAxis)
+	}
+	if m.Handedness != "" {
+		fmt.Fprintf(buf, "# handedness: %s\n", m.Handedness)
+	}
+	for _, note := range m.Notes {
+		fmt.Fprintf(buf, "# %s\n", note)
+	}
+	fmt.Fprintf(buf, "o %s\n", m.Shape)
+
+	// the geometry is written in the units of the model
+	length := unitLength(m.Units)
+
+	vertexCount := 1
+	for _, t := range m.Tiles {
+
+		group := m.Shape
+		groups := ""
+		if t.Face != "" {
+			group += "/" + t.Face
+			groups += group + " "
+		}
+		groups += fmt.Sprintf("%s/r%v %s", group, t.Row, tileName(m.Shape, t))
+		if t.Strip != 0 {
+			groups += " " + t.Name
+		}
+
+		fmt.Fprintf(buf, "g %s\n", groups)
+
+		for _, c := range t.Corners {
+			fmt.Fprintf(buf, "v %v %v %v \n", c[0]/length, c[1]/length, c[2]/length)
+		}
+
+		for _, uv := range t.UVs {
+			fmt.Fprintf(buf, "vt %v %v \n", uv[0], uv[1])
+		}
+
+		for _, n := range cornerNormals(t) {
+			fmt.Fprintf(buf, "vn %v %v %v \n", n[0], n[1], n[2])
+		}
+
+		order := [4]int{0, 1, 2, 3}
+		if t.Flip {
+			order = [4]int{0, 3, 2, 1}
+		}
+
+		face := "f"
+		for _, o := range order {
+			face += fmt.Sprintf(" %v/%v/%v", vertexCount+o, vertexCount+o, vertexCount+o)
+		}
+		fmt.Fprintln(buf, face)
+		vertexCount += 4
+	}
+
+	if err := buf.Flush(); err != nil {
+		return fmt.Errorf("error writing to obj %v", err)
+	}
+
+	return nil
+}
+
+// faceNormal returns the unit normal of the tile face,
+// following the winding of the face.
+func faceNormal(t ModelTile) [3]float64 {
+
+	// Newell's method, so quads that are not quite
+	// planar have a sensible normal
+	var n [3]float64
+	for i := 0; i < 4; i++ {
+		c, next := t.Corners[i], t.Corners[(i+1)%4]
+		n[0] += (c[1] - next[1]) * (c[2] + next[2])
+		n[1] += (c[2] - next[2]) * (c[0] + next[0])
+		n[2] += (c[0] - next[0]) * (c[1] + next[1])
+	}
+
+	if t.Flip {
+		n = [3]float64{-n[0], -n[1], -n[2]}
+	}
+
+	return unit(n)
+}
+
+// cornerNormals returns the normal of each corner, the analytic normals
+// are used if they are set, pointing in the same direction as the face.
+func cornerNormals(t ModelTile) [4][3]float64 {
+
+	face := faceNormal(t)
+	var normals [4][3]float64
+	for i, n := range t.Normals {
+		if n == [3]float64{} {
+			normals[i] = face
+			continue
+		}
+
+		n = unit(n)
+		if dot(n, face) < 0 {
+			n = [3]float64{-n[0], -n[1], -n[2]}
+		}
+		normals[i] = n
+	}
+
+	return normals
+}
+
+// unit returns the vector scaled to a length of 1
+func unit(v [3]float64) [3]float64 {
+	length := math.Sqrt(dot(v, v))
+	if length == 0 {
+		return v
+	}
+
+	return [3]float64{v[0] / length, v[1] / length, v[2] / length}
+}
@@ -0,0 +1,441 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	"image/png"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// add the command and its flags to the main handler
+func init() {
+	cmdPreview.Flags().StringVar(&objFile, "obj", "", "The obj file to preview")
+	cmdPreview.Flags().StringVar(&textureFile, "texture", "", "The test pattern image to texture the preview with, a uv checker is used if it is not given")
+	cmdPreview.Flags().StringVar(&outFile, "outputFile", "./output", "The name of the output png, each camera after the first is numbered")
+	cmdPreview.Flags().StringArrayVar(&cameras, "camera", nil, "The position of a camera as x,y,z, give the flag once for each view")
+	cmdPreview.Flags().StringVar(&lookAt, "lookAt", "", "The point the cameras look at as x,y,z, the default is the centre of the obj")
+	cmdPreview.Flags().Float64Var(&fieldOfView, "fov", 60, "The vertical field of view of the cameras in degrees")
+	cmdPreview.Flags().IntVar(&previewWidth, "width", 1280, "The width of the preview in pixels")
+	cmdPreview.Flags().IntVar(&previewHeight, "height", 720, "The height of the preview in pixels")
+
+	cmdBoth.AddCommand(cmdPreview)
+}
+
+// preview flags, the point the cameras look
+// at is also a flag of the frustum command
+var (
+	cameras       []string
+	lookAt        = ""
+	fieldOfView   = 60.0
+	previewWidth  = 1280
+	previewHeight = 720
+)
+
+var cmdPreview = &cobra.Command{
+	Use:   "preview",
+	Short: "render png previews of an obj",
+	Long: `
+	Render png previews of an obj with its test pattern, or a uv checker,
+	from each camera. Without a camera the obj is seen from the front,
+	the side its faces point to.
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if fieldOfView <= 0 || fieldOfView >= 180 {
+			return fmt.Errorf("the field of view must be between 0 and 180 degrees, got %v", fieldOfView)
+		}
+
+		if previewWidth <= 0 || previewHeight <= 0 {
+			return fmt.Errorf("the preview must be at least 1 pixel, got %vx%v", previewWidth, previewHeight)
+		}
+
+		objBytes, err := os.ReadFile(objFile)
+		if err != nil {
+			return err
+		}
+
+		tiles, err := parseOBJ(bytes.NewReader(objBytes))
+		if err != nil {
+			return err
+		}
+		model := &Model{Tiles: tiles, Handedness: previewHandedness(objBytes)}
+
+		tex := texture(checkerTexture)
+		if textureFile != "" {
+			fTex, err := os.Open(textureFile)
+			if err != nil {
+				return err
+			}
+			defer fTex.Close()
+
+			img, _, err := image.Decode(fTex)
+			if err != nil {
+				return fmt.Errorf("error reading the texture %s: %v", textureFile, err)
+			}
+			tex = imageTexture(img)
+		}
+
+		front := defaultCamera(model, previewUp(objBytes), fieldOfView)
+		if lookAt != "" {
+			front.target, err = parseVector(lookAt)
+			if err != nil {
+				return err
+			}
+		}
+
+		views := []previewCamera{front}
+		if len(cameras) > 0 {
+			views = views[:0]
+			for _, c := range cameras {
+				view := front
+				view.eye, err = parseVector(c)
+				if err != nil {
+					return err
+				}
+				views = append(views, view)
+			}
+		}
+
+		for i, view := range views {
+			img, err := renderPreview(model, tex, view, previewWidth, previewHeight)
+			if err != nil {
+				return err
+			}
+
+			name := outFile + ".png"
+			if i > 0 {
+				name = fmt.Sprintf("%s-%v.png", outFile, i+1)
+			}
+
+			fPNG, err := os.Create(name)
+			if err != nil {
+				return err
+			}
+
+			err = png.Encode(fPNG, img)
+			fPNG.Close()
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	},
+}
+
+// previewCamera is a perspective camera of a preview
+type previewCamera struct {
+	eye, target, up [3]float64
+	// fov is the vertical field of view in degrees
+	fov float64
+	// left is set for models in left handed coordinates,
+	// which are seen with right mirrored
+	left bool
+}
+
+// texture is the colour of the uv map at u, v
+type texture func(u, v float64) color.RGBA
+
+// the vertex of a triangle in the camera coordinates, with its uv
+type previewVertex struct {
+	p  [3]float64
+	uv [2]float64
+}
+
+// the colour of the preview where there is no tile
+var previewBackground = color.RGBA{R: 40, G: 40, B: 40, A: 255}
+
+// the closest the geometry can be to the camera
+const previewNear = 1e-3
+
+// imageTexture samples an image with the uv map, the v of
+// the uv map counts up from the bottom of the image.
+func imageTexture(img image.Image) texture {
+
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	w, h := b.Dx(), b.Dy()
+
+	return func(u, v float64) color.RGBA {
+		x := min(max(int(u*float64(w)), 0), w-1)
+		y := min(max(int((1-v)*float64(h)), 0), h-1)
+
+		return rgba.RGBAAt(x, y)
+	}
+}
+
+/*
+checkerTexture is a uv checker of 16 by 16 squares. The squares get redder
+along u and greener along v, so the direction of the uv map can be seen,
+and every other square is dark.
+*/
+func checkerTexture(u, v float64) color.RGBA {
+
+	const squares = 16
+	cu, cv := math.Floor(u*squares), math.Floor(v*squares)
+
+	c := color.RGBA{R: uint8(55 + 200*math.Min(max(u, 0), 1)), G: uint8(55 + 200*math.Min(max(v, 0), 1)), B: 160, A: 255}
+	if int(cu+cv)%2 != 0 {
+		c.R, c.G, c.B = c.R/3, c.G/3, c.B/3
+	}
+
+	return c
+}
+
+/*
+defaultCamera places a camera in front of the model, looking at its centre,
+far enough back for all of the model to be seen.
+
+The front of the model is the side its faces point to on average. For models
+where the faces point every way, such as closed rings, the model is seen from
+an angle above it.
+*/
+func defaultCamera(m *Model, up [3]float64, fov float64) previewCamera {
+
+	lo := [3]float64{math.Inf(1), math.Inf(1), math.Inf(1)}
+	hi := [3]float64{math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+	var facing [3]float64
+	for _, t := range m.Tiles {
+		for _, p := range t.Corners {
+			for k := range p {
+				lo[k] = math.Min(lo[k], p[k])
+				hi[k] = math.Max(hi[k], p[k])
+			}
+		}
+
+		n := faceNormal(t)
+		for k := range n {
+			facing[k] += n[k]
+		}
+	}
+
+	centre := [3]float64{(lo[0] + hi[0]) / 2, (lo[1] + hi[1]) / 2, (lo[2] + hi[2]) / 2}
+	radius := math.Sqrt(dot(sub(hi, lo), sub(hi, lo))) / 2
+
+	dir := unit(facing)
+	if len(m.Tiles) == 0 || math.Sqrt(dot(facing, facing)) < 0.1*float64(len(m.Tiles)) {
+		dir = unit([3]float64{2*up[0] - 1, 2*up[1] - 1, 2*up[2] - 1})
+	}
+
+	distance := radius/math.Sin(fov*math.Pi/360) + previewNear
+	eye := [3]float64{centre[0] + dir[0]*distance, centre[1] + dir[1]*distance, centre[2] + dir[2]*distance}
+
+	return previewCamera{eye: eye, target: centre, up: up, fov: fov, left: m.Handedness == HandednessLeft}
+}
+
+/*
+renderPreview renders the tiles of the model with the texture, as seen from the camera.
+
+Each tile is drawn as two triangles, with the uv map interpolated for the
+perspective of the camera, so straight lines of the texture stay straight.
+Both sides of the faces are drawn, lit from the camera so the shape can be seen.
+*/
+func renderPreview(m *Model, tex texture, cam previewCamera, width, height int) (*image.RGBA, error) {
+
+	right, up, forward, err := cam.basis()
+	if err != nil {
+		return nil, err
+	}
+
+	focal := float64(height) / 2 / math.Tan(cam.fov*math.Pi/360)
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: previewBackground}, image.Point{}, draw.Src)
+	// the depth buffer holds 1/z, so 0 is infinitely far away
+	depth := make([]float64, width*height)
+
+	for _, t := range m.Tiles {
+
+		var verts [4]previewVertex
+		for c, p := range t.Corners {
+			d := sub(p, cam.eye)
+			verts[c] = previewVertex{p: [3]float64{dot(d, right), dot(d, up), dot(d, forward)}, uv: t.UVs[c]}
+		}
+
+		// light the tile by how much it faces the camera
+		n := faceNormal(t)
+		centre := [3]float64{}
+		for _, v := range verts {
+			for k := range centre {
+				centre[k] += v.p[k] / 4
+			}
+		}
+		viewNormal := [3]float64{dot(n, right), dot(n, up), dot(n, forward)}
+		light := 0.35 + 0.65*math.Abs(dot(viewNormal, unit(centre)))
+
+		for _, tri := range [2][3]int{{0, 1, 2}, {0, 2, 3}} {
+			poly := clipNear([]previewVertex{verts[tri[0]], verts[tri[1]], verts[tri[2]]})
+			for i := 1; i+1 < len(poly); i++ {
+				drawTriangle(img, depth, [3]previewVertex{poly[0], poly[i], poly[i+1]}, tex, light, focal)
+			}
+		}
+	}
+
+	return img, nil
+}
+
+// basis returns the right, up and forward directions of the camera,
+// with right mirrored for left handed coordinates.
+func (cam previewCamera) basis() (right, up, forward [3]float64, err error) {
+
+	forward = unit(sub(cam.target, cam.eye))
+	if dot(forward, forward) == 0 {
+		return right, up, forward, fmt.Errorf("the camera at %v is looking at itself", cam.eye)
+	}
+
+	// pick another up if the camera looks along it
+	up = cam.up
+	if math.Abs(dot(forward, unit(up))) > 0.999 {
+		up = [3]float64{up[1], up[2], up[0]}
+	}
+	right = unit(cross(forward, up))
+	up = cross(right, forward)
+	if cam.left {
+		right = [3]float64{-right[0], -right[1], -right[2]}
+	}
+
+	return right, up, forward, nil
+}
+
+// clipNear clips a polygon to the part in front of the near plane of the camera.
+func clipNear(poly []previewVertex) []previewVertex {
+	return clipPlane(poly, func(p [3]float64) float64 { return p[2] - previewNear })
+}
+
+// clipPlane clips a polygon to the part on the side of a plane where
+// the signed distance to the plane is not negative.
+func clipPlane(poly []previewVertex, side func(p [3]float64) float64) []previewVertex {
+
+	out := make([]previewVertex, 0, len(poly)+1)
+	for i, a := range poly {
+		b := poly[(i+1)%len(poly)]
+		da, db := side(a.p), side(b.p)
+		aIn, bIn := da >= 0, db >= 0
+
+		if aIn {
+			out = append(out, a)
+		}
+
+		if aIn != bIn {
+			s := da / (da - db)
+			out = append(out, previewVertex{
+				p:  [3]float64{a.p[0] + s*(b.p[0]-a.p[0]), a.p[1] + s*(b.p[1]-a.p[1]), a.p[2] + s*(b.p[2]-a.p[2])},
+				uv: [2]float64{a.uv[0] + s*(b.uv[0]-a.uv[0]), a.uv[1] + s*(b.uv[1]-a.uv[1])},
+			})
+		}
+	}
+
+	return out
+}
+
+// drawTriangle draws a triangle in camera coordinates, where it is closer than
+// what has been drawn already.
+func drawTriangle(img *image.RGBA, depth []float64, tri [3]previewVertex, tex texture, light, focal float64) {
+
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+
+	// project to the screen, keeping 1/z and uv/z to
+	// interpolate the uv map with the perspective
+	var sx, sy, iz [3]float64
+	var uz, vz [3]float64
+	for i, v := range tri {
+		iz[i] = 1 / v.p[2]
+		sx[i] = float64(width)/2 + focal*v.p[0]*iz[i]
+		sy[i] = float64(height)/2 - focal*v.p[1]*iz[i]
+		uz[i], vz[i] = v.uv[0]*iz[i], v.uv[1]*iz[i]
+	}
+
+	area := (sx[1]-sx[0])*(sy[2]-sy[0]) - (sx[2]-sx[0])*(sy[1]-sy[0])
+	if math.Abs(area) < 1e-12 {
+		return
+	}
+
+	x0 := max(int(math.Floor(min(sx[0], sx[1], sx[2]))), 0)
+	x1 := min(int(math.Ceil(max(sx[0], sx[1], sx[2]))), width-1)
+	y0 := max(int(math.Floor(min(sy[0], sy[1], sy[2]))), 0)
+	y1 := min(int(math.Ceil(max(sy[0], sy[1], sy[2]))), height-1)
+
+	for y := y0; y <= y1; y++ {
+		py := float64(y) + 0.5
+		for x := x0; x <= x1; x++ {
+			px := float64(x) + 0.5
+
+			// the barycentric weights of the pixel centre
+			w0 := ((sx[1]-px)*(sy[2]-py) - (sx[2]-px)*(sy[1]-py)) / area
+			w1 := ((sx[2]-px)*(sy[0]-py) - (sx[0]-px)*(sy[2]-py)) / area
+			w2 := 1 - w0 - w1
+			if w0 < 0 || w1 < 0 || w2 < 0 {
+				continue
+			}
+
+			z := w0*iz[0] + w1*iz[1] + w2*iz[2]
+			if z <= depth[y*width+x] {
+				continue
+			}
+			depth[y*width+x] = z
+
+			c := tex((w0*uz[0]+w1*uz[1]+w2*uz[2])/z, (w0*vz[0]+w1*vz[1]+w2*vz[2])/z)
+			img.SetRGBA(x, y, color.RGBA{R: uint8(float64(c.R) * light), G: uint8(float64(c.G) * light), B: uint8(float64(c.B) * light), A: 255})
+		}
+	}
+}
+
+// previewUp returns the up of an obj, which is y if
+// it has been transformed to y up and z otherwise.
+func previewUp(obj []byte) [3]float64 {
+	if bytes.Contains(obj, []byte("# up axis: "+UpAxisY+"\n")) {
+		return [3]float64{0, 1, 0}
+	}
+
+	return [3]float64{0, 0, 1}
+}
+
+// previewHandedness returns the handedness of an obj, which is
+// left if it has been transformed to be left handed and right otherwise.
+func previewHandedness(obj []byte) string {
+	if bytes.Contains(obj, []byte("# handedness: "+HandednessLeft+"\n")) {
+		return HandednessLeft
+	}
+
+	return HandednessRight
+}
+
+// parseVector reads a vector given as x,y,z
+func parseVector(s string) ([3]float64, error) {
+
+	var v [3]float64
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("%q is not a vector of x,y,z", s)
+	}
+
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return v, fmt.Errorf("%q is not a vector of x,y,z: %v", s, err)
+		}
+		v[i] = f
+	}
+
+	return v, nil
+}
+
+// cross returns the cross product a x b
+func cross(a, b [3]float64) [3]float64 {
+	return [3]float64{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
@@ -0,0 +1,166 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/mrmxf/opentsg-modules/opentsg-core/gridgen"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	AddShapeToHandler[Scene]("A scene of several shapes, each moved into place")
+}
+
+// Scene properties
+type Scene struct {
+	// Shapes are the shapes of the scene
+	Shapes []SceneShape `json:"shapes" yaml:"shapes"`
+	// the bezel and seam gap of the shapes without their own
+	Seams SeamConfig `json:",inline" yaml:",inline"`
+	// shape name of "scene"
+	ShapeName
+}
+
+// SceneShape is a shape of a scene, and where it is in the scene.
+type SceneShape struct {
+	// Name is the name of the shape in the scene, which its tiles are
+	// named by. It defaults to the shape of the config.
+	Name string `json:"name" yaml:"name"`
+	// Translate moves the shape by x, y and z
+	Translate [3]float64 `json:"translate" yaml:"translate"`
+	// Rotate is the rotation of the shape in degrees, around
+	// the x, then the y, then the z axis.
+	Rotate [3]float64 `json:"rotate" yaml:"rotate"`
+	// Scale scales the shape around 0,0,0.
+	// The shape is not scaled if it is 0.
+	Scale float64 `json:"scale" yaml:"scale"`
+	// Config is the configuration of the shape, as it would be in its own file.
+	Config map[string]any `json:"config" yaml:"config"`
+}
+
+func (s Scene) ObjType() string {
+	return "scene"
+}
+
+// Generate generates a TSIG and OBJ for a scene.
+func (s Scene) Generate(wObj, wTsig io.Writer) error {
+	return generate(s, wObj, wTsig)
+}
+
+// withSeams sets the seams of the scene, unless it has its own.
+func (s Scene) withSeams(seams SeamConfig) Generator {
+	s.Seams = s.Seams.within(seams)
+	return s
+}
+
+/*
+Build builds the model of a scene, of every shape in the scene merged together.
+
+Each shape is scaled, then rotated, then translated into place. The tiles of a
+shape are on the face of its name, e.g. scene/left/r0c0. The flat layouts of the
+shapes are put side by side, from left to right in the order of the scene.
+
+Each shape has the bezel and seam gap of its config, or of the scene if its
+config has neither. A shape can give its own units, the lengths of every shape
+are converted to millimetres when the config is read, so the shapes are all
+built in the same units.
+*/
+func (s Scene) Build() (*Model, error) {
+
+	if len(s.Shapes) == 0 {
+		return nil, fmt.Errorf("a scene needs at least one shape")
+	}
+
+	names := map[string]bool{}
+	parts := make([]modelPart, len(s.Shapes))
+	x := 0
+	for i, sh := range s.Shapes {
+
+		shape, _ := sh.Config["shape"].(string)
+		name := sh.Name
+		if name == "" {
+			name = shape
+		}
+
+		if name == "" {
+			return nil, fmt.Errorf("scene shape %v has no shape in its config", i+1)
+		}
+
+		if names[name] {
+			return nil, fmt.Errorf("there is more than one scene shape named %q, give each shape a unique name", name)
+		}
+		names[name] = true
+
+		m, err := sh.build(shape, s.Seams)
+		if err != nil {
+			return nil, fmt.Errorf("error building scene shape %q: %v", name, err)
+		}
+
+		parts[i] = modelPart{model: m, face: name, at: gridgen.XY{X: x, Y: 0}}
+		x += m.Flat.X1 - m.Flat.X0
+	}
+
+	return mergeModels(s.ObjType(), parts), nil
+}
+
+// build builds the shape of the config, with its own seams
+// or those of the scene, and moves it into place.
+func (sh SceneShape) build(shape string, scene SeamConfig) (*Model, error) {
+
+	scale := sh.Scale
+	if scale == 0 {
+		scale = 1
+	}
+
+	// a mirrored shape would read backwards, so shapes can only be turned
+	if scale < 0 {
+		return nil, fmt.Errorf("the scale must be greater than 0, got %v, use rotate to turn the shape around", scale)
+	}
+
+	shp, ok := shapes[shape]
+	if !ok {
+		return nil, fmt.Errorf("unknown shape %q, run \"tsig list\" for the available shapes", shape)
+	}
+
+	conf, err := yaml.Marshal(sh.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := shp.unmarshaler(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	// shapes made of parts seam each part as they build it
+	p, parts := g.(seamedParts)
+	if parts {
+		g = p.withSeams(scene)
+	}
+
+	m, err := buildModel(g)
+	if err != nil {
+		return nil, err
+	}
+
+	if !parts {
+		var seams SeamConfig
+		if err := yaml.Unmarshal(conf, &seams); err != nil {
+			return nil, err
+		}
+
+		if err := applySeams(m, seams.within(scene)); err != nil {
+			return nil, err
+		}
+	}
+
+	moveTiles(m.Tiles, rotation(sh.Rotate), scale, sh.Translate)
+
+	return m, nil
+}
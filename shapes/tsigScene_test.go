@@ -0,0 +1,183 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mrmxf/opentsg-modules/opentsg-core/gridgen"
+)
+
+// readScene builds a scene from its config, as the handler reads it
+func readScene(t *testing.T, conf string) *Model {
+	t.Helper()
+
+	b, _, _, err := applyUnits([]byte(conf))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := unmarshalGenerator[Scene](b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := buildModel(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
+
+func TestSceneUnits(t *testing.T) {
+
+	// a wall of two 1x0.5m tiles, in the units of its block
+	wall := func(units string, length float64) string {
+		conf := "      shape: flatwall\n      dx: 10\n      dy: 10\n"
+		if units != "" {
+			conf += "      units: " + units + "\n"
+		}
+		for _, f := range []struct {
+			name  string
+			value float64
+		}{{"tileWidth", 0.5}, {"tileHeight", 0.5}, {"wallWidth", 1}, {"wallHeight", 0.5}} {
+			conf += "      " + f.name + ": " + strconv.FormatFloat(f.value*length, 'g', -1, 64) + "\n"
+		}
+		return conf
+	}
+
+	for _, tc := range []struct {
+		name string
+		conf string
+		// the bottom left and top right corners of the first tile of each
+		// shape, in millimetres
+		corners map[string][2][3]float64
+	}{
+		{name: "shapes in the units of the scene",
+			conf: "shape: scene\nunits: m\nshapes:\n" +
+				"  - name: a\n    config:\n" + wall("", 1) +
+				"  - name: b\n    translate: [2, 0, 0]\n    config:\n" + wall("", 1),
+			corners: map[string][2][3]float64{
+				"scene/a/r0c0": {{0, 0, 0}, {500, 0, 500}},
+				"scene/b/r0c0": {{2000, 0, 0}, {2500, 0, 500}}}},
+		{name: "shapes with their own units",
+			conf: "shape: scene\nunits: m\nshapes:\n" +
+				"  - name: a\n    config:\n" + wall("", 1) +
+				"  - name: b\n    translate: [2, 0, 0]\n    config:\n" + wall("mm", 1000) +
+				"  - name: c\n    translate: [400, 0, 0]\n    units: cm\n    config:\n" + wall("", 100),
+			corners: map[string][2][3]float64{
+				"scene/a/r0c0": {{0, 0, 0}, {500, 0, 500}},
+				"scene/b/r0c0": {{2000, 0, 0}, {2500, 0, 500}},
+				"scene/c/r0c0": {{4000, 0, 0}, {4500, 0, 500}}}},
+		{name: "stage with its own units",
+			conf: "shape: scene\nunits: m\nshapes:\n" +
+				"  - name: a\n    config:\n" + wall("", 1) +
+				"  - name: s\n    config:\n      shape: stage\n      units: mm\n      wall:\n" +
+				"        shape: flatwall\n        tileWidth: 500\n        tileHeight: 500\n        wallWidth: 1000\n        wallHeight: 500\n        dx: 10\n        dy: 10\n" +
+				"      floor:\n        tileWidth: 500\n        tileHeight: 500\n        width: 1000\n        depth: 500\n        dx: 10\n        dy: 10\n",
+			corners: map[string][2][3]float64{
+				"scene/a/r0c0":      {{0, 0, 0}, {500, 0, 500}},
+				"scene/s/wall/r0c0": {{0, 500, 0}, {0, 0, 500}}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := readScene(t, tc.conf)
+
+			found := 0
+			for _, tile := range m.Tiles {
+				want, ok := tc.corners[tile.Name]
+				if !ok {
+					continue
+				}
+				found++
+
+				if !near(tile.Corners[0], want[0]) || !near(tile.Corners[2], want[1]) {
+					t.Errorf("%s is from %v to %v, want %v to %v", tile.Name, tile.Corners[0], tile.Corners[2], want[0], want[1])
+				}
+			}
+
+			if found != len(tc.corners) {
+				t.Errorf("found %v of the tiles %v", found, tc.corners)
+			}
+		})
+	}
+}
+
+func TestScene(t *testing.T) {
+
+	// a wall of two 0.5 tiles
+	wall := func() map[string]any {
+		return map[string]any{"shape": "flatwall", "tileWidth": 0.5, "tileHeight": 0.5, "wallWidth": 1, "wallHeight": 0.5, "dx": 10, "dy": 10}
+	}
+
+	for _, tc := range []struct {
+		name   string
+		shapes []SceneShape
+		// the tiles of each face, the canvas, and the bottom
+		// left and top right corners of some tiles
+		faces   map[string]int
+		flat    gridgen.XY2D
+		corners map[string][2][3]float64
+		err     string
+	}{
+		{name: "one shape named by its shape", shapes: []SceneShape{{Config: wall()}},
+			faces: map[string]int{"flatwall": 2}, flat: gridgen.XY2D{X1: 20, Y1: 10},
+			corners: map[string][2][3]float64{"scene/flatwall/r0c1": {{0.5, 0, 0}, {1, 0, 0.5}}}},
+		{name: "shapes side by side", shapes: []SceneShape{{Name: "a", Config: wall()},
+			{Name: "b", Translate: [3]float64{2, 0, 0}, Config: map[string]any{"shape": "cube", "tileWidth": 0.5, "tileHeight": 0.5, "cubeWidth": 1, "cubeHeight": 1, "cubeDepth": 1, "dx": 10, "dy": 10}}},
+			faces: map[string]int{"a": 2, "b": 20}, flat: gridgen.XY2D{X1: 20 + 60, Y1: 60}},
+		{name: "scaled, rotated and moved", shapes: []SceneShape{{Name: "a", Scale: 2, Rotate: [3]float64{0, 0, 90}, Translate: [3]float64{5, 0, 0}, Config: wall()}},
+			faces: map[string]int{"a": 2}, flat: gridgen.XY2D{X1: 20, Y1: 10},
+			corners: map[string][2][3]float64{"scene/a/r0c0": {{5, 0, 0}, {5, 1, 1}}}},
+		{name: "no shapes",
+			err: "a scene needs at least one shape"},
+		{name: "no shape in the config", shapes: []SceneShape{{Name: "a", Config: map[string]any{}}},
+			err: "unknown shape"},
+		{name: "no name or shape", shapes: []SceneShape{{Config: map[string]any{}}},
+			err: "has no shape in its config"},
+		{name: "the same name twice", shapes: []SceneShape{{Config: wall()}, {Config: wall()}},
+			err: `more than one scene shape named "flatwall"`},
+		{name: "mirrored", shapes: []SceneShape{{Scale: -1, Config: wall()}},
+			err: "the scale must be greater than 0"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := Scene{Shapes: tc.shapes}.Build()
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("got the error %v, want an error of %q", err, tc.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			faces := map[string]int{}
+			for _, tile := range m.Tiles {
+				faces[strings.SplitN(tile.Face, "/", 2)[0]]++
+				if want, ok := tc.corners[tile.Name]; ok && (!near(tile.Corners[0], want[0]) || !near(tile.Corners[2], want[1])) {
+					t.Errorf("%s is from %v to %v, want %v to %v", tile.Name, tile.Corners[0], tile.Corners[2], want[0], want[1])
+				}
+			}
+
+			if len(faces) != len(tc.faces) || m.Flat != tc.flat {
+				t.Fatalf("got the faces %v on a %v canvas, want %v on %v", faces, m.Flat, tc.faces, tc.flat)
+			}
+			for face, count := range tc.faces {
+				if faces[face] != count {
+					t.Errorf("got %v %s tiles, want %v", faces[face], face, count)
+				}
+			}
+
+			if v := validateModel(m, 1); v.problems() > 0 {
+				t.Errorf("the scene does not fit its canvas: %v %v %v", v.Mismatches, v.Overlaps, v.Outside)
+			}
+		})
+	}
+}
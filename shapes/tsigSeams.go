@@ -0,0 +1,263 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"fmt"
+	"math"
+)
+
+// SeamConfig is the bezel and seam gap of the tiles in a configuration.
+type SeamConfig struct {
+	// Bezel is the unlit border around each tile,
+	// and SeamGap is the mounting gap between tiles.
+	// They are in the same units as the tile dimensions.
+	Bezel   float64 `json:"bezel" yaml:"bezel"`
+	SeamGap float64 `json:"seamGap" yaml:"seamGap"`
+	// DeadZone adds the pixels that would be in the bezels and
+	// gaps to the flat layout, so the flat layout is not contiguous.
+	DeadZone bool `json:"deadZone" yaml:"deadZone"`
+}
+
+// inset is the distance the lit area of a tile is inset from the tile pitch
+func (s SeamConfig) inset() float64 {
+	return s.Bezel + s.SeamGap/2
+}
+
+// within returns the seams of a part of a shape, which keeps its own bezel and
+// seam gap, or takes those of the whole shape if it has neither.
+func (s SeamConfig) within(whole SeamConfig) SeamConfig {
+	if s.Bezel == 0 && s.SeamGap == 0 {
+		s.Bezel, s.SeamGap = whole.Bezel, whole.SeamGap
+	}
+	s.DeadZone = s.DeadZone || whole.DeadZone
+
+	return s
+}
+
+// seamedParts is a shape made of the parts of other shapes, such as a stage,
+// that applies the seams of each part as it is built, as each part can have
+// tiles of its own.
+type seamedParts interface {
+	// withSeams returns the shape with the seams for
+	// the parts that have neither a bezel nor a seam gap.
+	withSeams(seams SeamConfig) Generator
+}
+
+/*
+applySeams insets the lit area of every tile inside its tile pitch,
+by the bezel and half the seam gap on each side.
+
+The tiles keep their positions, so the obj quads are shrunk about the same
+pitch. The strips of a tile are only inset on the edges of the whole tile.
+The size of each tile is found from its corners, so the tiles do not have to
+be the same size.
+
+The flat layout stays contiguous, unless dead zones are used. Then the flat
+layout is spread out by the pixels that the bezels and gaps would have, at
+the pixel pitch of the tiles, and the uv map follows the flat layout. Dead
+zones need every tile to have the same pixels.
+*/
+func applySeams(m *Model, s SeamConfig) error {
+
+	if s.Bezel == 0 && s.SeamGap == 0 {
+		return nil
+	}
+
+	if s.Bezel < 0 || s.SeamGap < 0 {
+		return fmt.Errorf("the bezel and seam gap can not be negative, got a bezel of %v and a seam gap of %v", s.Bezel, s.SeamGap)
+	}
+
+	d := s.inset()
+	units := carveUnits(m.Tiles)
+
+	// the size of each whole tile, before it is inset
+	sizes := make([][2]float64, len(units))
+	for i, u := range units {
+		sizes[i] = tileSize(m.Tiles, u.tiles)
+		if 2*d >= sizes[i][0] || 2*d >= sizes[i][1] {
+			return fmt.Errorf("a bezel of %v and a seam gap of %v leave no lit area in the %.4gx%.4g tile %s", s.Bezel, s.SeamGap, sizes[i][0], sizes[i][1], u.name)
+		}
+	}
+
+	// the tiles as pixels of the uv map, which can differ
+	// from the flat layout e.g. if the uv map is mirrored
+	width, height := float64(m.Flat.X1-m.Flat.X0), float64(m.Flat.Y1-m.Flat.Y0)
+	toPixel := func(uv [2]float64) [2]float64 {
+		return [2]float64{uv[0] * width, (1 - uv[1]) * height}
+	}
+
+	uvBounds := make([][4]float64, len(units))
+	for i, u := range units {
+		b := [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+		for _, t := range u.tiles {
+			for _, uv := range m.Tiles[t].UVs {
+				p := toPixel(uv)
+				b = [4]float64{math.Min(b[0], p[0]), math.Min(b[1], p[1]), math.Max(b[2], p[0]), math.Max(b[3], p[1])}
+			}
+		}
+		uvBounds[i] = b
+	}
+
+	for _, u := range units {
+		// the strips are inset from the original corners of the tile
+		strips := make([]ModelTile, len(u.tiles))
+		for i, t := range u.tiles {
+			strips[i] = m.Tiles[t]
+		}
+
+		for _, t := range u.tiles {
+			insetTile(&m.Tiles[t], d, strips)
+		}
+	}
+
+	if !s.DeadZone {
+		return nil
+	}
+
+	// the flat layout is scaled as a whole, so the tiles need the same pixels
+	dx, dy := tilePixels(m.Tiles, units[0].tiles)
+	tileWidth, tileHeight := 0.0, 0.0
+	for i, u := range units {
+		if w, h := tilePixels(m.Tiles, u.tiles); w != dx || h != dy {
+			return fmt.Errorf("dead zones need every tile to have the same pixels, tile %s is %vx%v pixels and tile %s is %vx%v pixels", units[0].name, dx, dy, u.name, w, h)
+		}
+		tileWidth += sizes[i][0] / float64(len(units))
+		tileHeight += sizes[i][1] / float64(len(units))
+	}
+
+	// the dead zone pixels on each side of a tile, at the pixel pitch of the lit area
+	zoneX := math.Round(d * float64(dx) / (tileWidth - 2*d))
+	zoneY := math.Round(d * float64(dy) / (tileHeight - 2*d))
+	scaleX, scaleY := (float64(dx)+2*zoneX)/float64(dx), (float64(dy)+2*zoneY)/float64(dy)
+
+	newWidth, newHeight := math.Round(width*scaleX), math.Round(height*scaleY)
+	for i, u := range units {
+		// the flat layout
+		x := int(math.Round(float64(u.bounds.X0)*scaleX + zoneX))
+		y := int(math.Round(float64(u.bounds.Y0)*scaleY + zoneY))
+		for _, t := range u.tiles {
+			m.Tiles[t].Flat.X += x - u.bounds.X0
+			m.Tiles[t].Flat.Y += y - u.bounds.Y0
+		}
+
+		// the uv map is moved in the same way
+		b := uvBounds[i]
+		ux, uy := math.Round(b[0]*scaleX+zoneX), math.Round(b[1]*scaleY+zoneY)
+		for _, t := range u.tiles {
+			for c, uv := range m.Tiles[t].UVs {
+				p := toPixel(uv)
+				m.Tiles[t].UVs[c] = [2]float64{(p[0] - b[0] + ux) / newWidth, 1 - (p[1]-b[1]+uy)/newHeight}
+			}
+		}
+	}
+
+	m.Flat.X1 = m.Flat.X0 + int(newWidth)
+	m.Flat.Y1 = m.Flat.Y0 + int(newHeight)
+	m.Notes = append(m.Notes, fmt.Sprintf("the flat layout has dead zones of %v pixels either side of each tile and %v pixels above and below", zoneX, zoneY))
+
+	return nil
+}
+
+// tileSize returns the width and height of a whole tile from the corners of
+// its strips, as the mean of the opposite edges. The strips are stacked up the tile.
+func tileSize(tiles []ModelTile, strips []int) [2]float64 {
+
+	var size [2]float64
+	for _, i := range strips {
+		c := tiles[i].Corners
+		size[0] = math.Max(size[0], (distance(c[0], c[1])+distance(c[3], c[2]))/2)
+		size[1] += (distance(c[0], c[3]) + distance(c[1], c[2])) / 2
+	}
+
+	return size
+}
+
+// tilePixels returns the pixels across and up a whole tile, from its strips
+func tilePixels(tiles []ModelTile, strips []int) (int, int) {
+
+	x, y := 0, 0
+	for _, i := range strips {
+		x, y = max(x, tiles[i].Size.X), y+tiles[i].Size.Y
+	}
+
+	return x, y
+}
+
+/*
+insetTile moves the corners of a tile inwards by d. Only the sides that are
+not shared with another strip of the same tile are moved, which are all the
+sides if the tile is not a strip.
+
+The corners are moved across the bilinear surface of the quad,
+so curved tiles stay on their chords.
+*/
+func insetTile(t *ModelTile, d float64, strips []ModelTile) {
+
+	// shared checks if the side from corner a to b is a side of another strip
+	shared := func(a, b int) bool {
+		pa, pb := t.Corners[a], t.Corners[b]
+		tol := 1e-6 * distance(pa, pb)
+		for _, o := range strips {
+			if o.Corners == t.Corners {
+				continue
+			}
+
+			for k := 0; k < 4; k++ {
+				oa, ob := o.Corners[k], o.Corners[(k+1)%4]
+				if (distance(pa, oa) <= tol && distance(pb, ob) <= tol) || (distance(pa, ob) <= tol && distance(pb, oa) <= tol) {
+					return true
+				}
+			}
+		}
+
+		return false
+	}
+
+	// the fraction of an edge that d is
+	fraction := func(a, b int) float64 {
+		length := distance(t.Corners[a], t.Corners[b])
+		if length == 0 {
+			return 0
+		}
+		return d / length
+	}
+
+	left, right, bottom, top := !shared(3, 0), !shared(1, 2), !shared(0, 1), !shared(2, 3)
+
+	// inset gives the fraction of an edge if the side is inset
+	inset := func(side bool, a, b int) float64 {
+		if side {
+			return fraction(a, b)
+		}
+		return 0
+	}
+
+	// the position on the quad of each new corner, using the lengths
+	// of the edges at the corner so the strips of a tile stay joined
+	at := [4][2]float64{
+		{inset(left, 0, 1), inset(bottom, 0, 3)},
+		{1 - inset(right, 0, 1), inset(bottom, 1, 2)},
+		{1 - inset(right, 3, 2), 1 - inset(top, 1, 2)},
+		{inset(left, 3, 2), 1 - inset(top, 0, 3)},
+	}
+
+	bilinear := func(v [4][3]float64, a, b float64) [3]float64 {
+		var p [3]float64
+		for i := range p {
+			p[i] = (1-a)*(1-b)*v[0][i] + a*(1-b)*v[1][i] + a*b*v[2][i] + (1-a)*b*v[3][i]
+		}
+		return p
+	}
+
+	c, normals := t.Corners, t.Normals
+	for i, ab := range at {
+		t.Corners[i] = bilinear(c, ab[0], ab[1])
+		if normals != [4][3]float64{} {
+			t.Normals[i] = unit(bilinear(normals, ab[0], ab[1]))
+		}
+	}
+}
@@ -0,0 +1,105 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"testing"
+
+	"github.com/mrmxf/opentsg-modules/opentsg-core/gridgen"
+)
+
+func TestApplySeams(t *testing.T) {
+
+	// stripped is a 1x1 tile split into a bottom and a top strip
+	stripped := func(t *testing.T) *Model {
+		return &Model{Flat: gridgen.XY2D{X1: 10, Y1: 10}, Tiles: []ModelTile{
+			{Name: "t/s0", Tags: []string{"tile:t"}, Corners: [4][3]float64{{0, 0, 0}, {1, 0, 0}, {1, 0, 0.5}, {0, 0, 0.5}},
+				Flat: gridgen.XY{Y: 5}, Size: gridgen.XY{X: 10, Y: 5}},
+			{Name: "t/s1", Tags: []string{"tile:t"}, Corners: [4][3]float64{{0, 0, 0.5}, {1, 0, 0.5}, {1, 0, 1}, {0, 0, 1}},
+				Size: gridgen.XY{X: 10, Y: 5}},
+		}}
+	}
+
+	wall := func(t *testing.T) *Model { return testWall(t, 3, 2) }
+
+	for _, tc := range []struct {
+		name  string
+		model func(t *testing.T) *Model
+		seams SeamConfig
+		// corners are the corners of the first tiles after the inset
+		corners [][4][3]float64
+		err     bool
+	}{
+		{name: "bezel", model: wall, seams: SeamConfig{Bezel: 0.1},
+			corners: [][4][3]float64{{{0.1, 0, 0.1}, {0.9, 0, 0.1}, {0.9, 0, 0.9}, {0.1, 0, 0.9}}}},
+		{name: "seam gap", model: wall, seams: SeamConfig{SeamGap: 0.2},
+			corners: [][4][3]float64{{{0.1, 0, 0.1}, {0.9, 0, 0.1}, {0.9, 0, 0.9}, {0.1, 0, 0.9}}}},
+		{name: "strips are inset on the edges of the tile", model: stripped, seams: SeamConfig{Bezel: 0.1},
+			corners: [][4][3]float64{
+				{{0.1, 0, 0.1}, {0.9, 0, 0.1}, {0.9, 0, 0.5}, {0.1, 0, 0.5}},
+				{{0.1, 0, 0.5}, {0.9, 0, 0.5}, {0.9, 0, 0.9}, {0.1, 0, 0.9}}}},
+		{name: "no seams", model: wall,
+			corners: [][4][3]float64{{{0, 0, 0}, {1, 0, 0}, {1, 0, 1}, {0, 0, 1}}}},
+		{name: "no lit area", model: wall, seams: SeamConfig{Bezel: 0.4, SeamGap: 0.2}, err: true},
+		{name: "negative bezel", model: wall, seams: SeamConfig{Bezel: -0.1}, err: true},
+		{name: "dead zones of different tiles", model: func(t *testing.T) *Model {
+			m := testWall(t, 3, 2)
+			m.Tiles[0].Size.X = 5
+			return m
+		}, seams: SeamConfig{Bezel: 0.1, DeadZone: true}, err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := tc.model(t)
+
+			err := applySeams(m, tc.seams)
+			if tc.err {
+				if err == nil {
+					t.Fatal("got no error, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i, want := range tc.corners {
+				for c := range want {
+					if !near(m.Tiles[i].Corners[c], want[c]) {
+						t.Errorf("%s corner %v is %v, want %v", m.Tiles[i].Name, c, m.Tiles[i].Corners[c], want[c])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestApplySeamsDeadZone(t *testing.T) {
+
+	// the tiles of the bezel example, with 12 pixels of dead zone either side
+	m, err := FlatWall{TileHeight: 0.5, TileWidth: 0.5, WallWidth: 1, WallHeight: 0.5, Dx: 476, Dy: 476}.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := applySeams(m, SeamConfig{Bezel: 0.01, SeamGap: 0.004, DeadZone: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := (gridgen.XY2D{X1: 1000, Y1: 500}); m.Flat != want {
+		t.Errorf("the flat canvas is %v, want %v", m.Flat, want)
+	}
+
+	for i, want := range []gridgen.XY{{X: 12, Y: 12}, {X: 512, Y: 12}} {
+		if m.Tiles[i].Flat != want || m.Tiles[i].Size != (gridgen.XY{X: 476, Y: 476}) {
+			t.Errorf("%s is at %v of %v, want %v of 476x476", m.Tiles[i].Name, m.Tiles[i].Flat, m.Tiles[i].Size, want)
+		}
+	}
+
+	// the uv map follows the flat layout
+	if v := validateModel(m, 1); v.problems() > 0 {
+		t.Errorf("the tiles do not match their uv map: %v %v %v", v.Mismatches, v.Overlaps, v.Outside)
+	}
+}
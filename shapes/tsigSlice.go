@@ -0,0 +1,246 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mrmxf/opentsg-modules/opentsg-core/gridgen"
+	"github.com/spf13/cobra"
+)
+
+// add the command and its flags to the main handler
+func init() {
+	cmdSlice.Flags().StringVar(&tsigFile, "tsig", "", "The TSIG file of the test pattern")
+	cmdSlice.Flags().StringVar(&imageFile, "image", "", "The flat test pattern image to slice, png or jpeg")
+	cmdSlice.Flags().StringVar(&outputDir, "outputDir", "./slices", "The folder the tile images and index.csv are written to")
+
+	cmdBoth.AddCommand(cmdSlice)
+}
+
+// slice flags
+var (
+	imageFile = ""
+	outputDir = "./slices"
+)
+
+var cmdSlice = &cobra.Command{
+	Use:   "slice",
+	Short: "slice a flat test pattern into an image per tile",
+	Long: `
+	Slice the flat test pattern image of a TSIG into an image for each tile,
+	named after the tile, with an index.csv of the tiles.
+	Tiles split into strips are put back together as the whole tile.
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		tsigBytes, err := os.ReadFile(tsigFile)
+		if err != nil {
+			return err
+		}
+
+		var tpig gridgen.TPIG
+		err = json.Unmarshal(tsigBytes, &tpig)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %v", tsigFile, err)
+		}
+
+		fImg, err := os.Open(imageFile)
+		if err != nil {
+			return err
+		}
+		defer fImg.Close()
+
+		img, _, err := image.Decode(fImg)
+		if err != nil {
+			return fmt.Errorf("error reading the image %s: %v", imageFile, err)
+		}
+
+		err = sliceFence(img, tpig.Dimensions.Flat)
+		if err != nil {
+			return err
+		}
+
+		err = os.MkdirAll(outputDir, 0755)
+		if err != nil {
+			return err
+		}
+
+		fIndex, err := os.Create(filepath.Join(outputDir, "index.csv"))
+		if err != nil {
+			return err
+		}
+		defer fIndex.Close()
+
+		index := csv.NewWriter(fIndex)
+		index.Write([]string{"tile", "file", "x", "y", "width", "height", "strips"})
+
+		for _, t := range physicalTiles(tpig) {
+			file, err := sliceFile(outputDir, t.Name)
+			if err != nil {
+				return err
+			}
+
+			err = os.MkdirAll(filepath.Dir(file), 0755)
+			if err != nil {
+				return err
+			}
+
+			fPNG, err := os.Create(file)
+			if err != nil {
+				return err
+			}
+
+			slice := t.slice(img, tpig.Dimensions.Flat)
+			err = png.Encode(fPNG, slice)
+			fPNG.Close()
+			if err != nil {
+				return err
+			}
+
+			rel, _ := filepath.Rel(outputDir, file)
+			b := t.bounds()
+			index.Write([]string{t.Name, filepath.ToSlash(rel), fmt.Sprint(b.Min.X), fmt.Sprint(b.Min.Y),
+				fmt.Sprint(slice.Bounds().Dx()), fmt.Sprint(slice.Bounds().Dy()), fmt.Sprint(len(t.Strips))})
+		}
+
+		index.Flush()
+
+		return index.Error()
+	},
+}
+
+// physicalTile is a tile as it is built, with the TSIG
+// tiles of its strips, or itself if it is a whole tile.
+type physicalTile struct {
+	Name   string
+	Strips []gridgen.Tilelayout
+}
+
+/*
+physicalTiles groups the tiles of a TSIG into the physical tiles they are
+part of, in the order they are first found in the TSIG.
+
+Strips are grouped by their tile: tag, which is the name of the whole tile,
+and are sorted from the top of the flat canvas to the bottom.
+*/
+func physicalTiles(tpig gridgen.TPIG) []physicalTile {
+
+	tiles := []physicalTile{}
+	index := map[string]int{}
+	for _, t := range tpig.Tilelayout {
+		name := t.Name
+		for _, tag := range t.Tags {
+			if whole, ok := strings.CutPrefix(tag, "tile:"); ok {
+				name = whole
+			}
+		}
+
+		i, ok := index[name]
+		if !ok {
+			i = len(tiles)
+			index[name] = i
+			tiles = append(tiles, physicalTile{Name: name})
+		}
+		tiles[i].Strips = append(tiles[i].Strips, t)
+	}
+
+	for _, t := range tiles {
+		sort.SliceStable(t.Strips, func(a, b int) bool { return t.Strips[a].Layout.Flat.Y < t.Strips[b].Layout.Flat.Y })
+	}
+
+	return tiles
+}
+
+// bounds returns the region of the flat canvas the tile covers,
+// as the union of the regions of all its strips.
+func (p physicalTile) bounds() image.Rectangle {
+
+	var r image.Rectangle
+	for i, s := range p.Strips {
+		sr := image.Rect(s.Layout.Flat.X, s.Layout.Flat.Y, s.Layout.Flat.X+s.Layout.Size.X, s.Layout.Flat.Y+s.Layout.Size.Y)
+		if i == 0 {
+			r = sr
+			continue
+		}
+		r = r.Union(sr)
+	}
+
+	return r
+}
+
+/*
+slice crops the tile from the flat canvas image. The canvas
+is the flat dimensions of the TSIG the image was made for.
+
+The strips of a tile are shifted along the flat canvas, so the uv map of the
+shape is square. They are put back under each other, lined up on the left, so
+the slice is the image the whole tile shows.
+*/
+func (p physicalTile) slice(img image.Image, canvas gridgen.XY2D) image.Image {
+
+	top, width, height := 0, 0, 0
+	for i, s := range p.Strips {
+		if i == 0 {
+			top = s.Layout.Flat.Y
+		}
+		width = max(width, s.Layout.Size.X)
+		height = max(height, s.Layout.Flat.Y+s.Layout.Size.Y-top)
+	}
+
+	out := newLike(img, image.Rect(0, 0, width, height))
+	origin := img.Bounds().Min
+	for _, s := range p.Strips {
+		dst := image.Rect(0, s.Layout.Flat.Y-top, s.Layout.Size.X, s.Layout.Flat.Y-top+s.Layout.Size.Y)
+		src := image.Pt(origin.X+s.Layout.Flat.X-canvas.X0, origin.Y+s.Layout.Flat.Y-canvas.Y0)
+		draw.Draw(out, dst, img, src, draw.Src)
+	}
+
+	return out
+}
+
+// newLike returns an image of the size, that keeps the 16 bit
+// colours of 16 bit images.
+func newLike(img image.Image, r image.Rectangle) draw.Image {
+	switch img.(type) {
+	case *image.RGBA64, *image.NRGBA64, *image.Gray16:
+		return image.NewRGBA64(r)
+	default:
+		return image.NewRGBA(r)
+	}
+}
+
+// sliceFile returns the path of the slice of a tile in the
+// directory, with the parts of the tile name as folders.
+func sliceFile(dir, name string) (string, error) {
+
+	file := filepath.FromSlash(name) + ".png"
+	if !filepath.IsLocal(file) {
+		return "", fmt.Errorf("the tile name %q can not be used as a file name", name)
+	}
+
+	return filepath.Join(dir, file), nil
+}
+
+// sliceFence checks the image is the size of the flat canvas of the TSIG.
+func sliceFence(img image.Image, canvas gridgen.XY2D) error {
+
+	width, height := canvas.X1-canvas.X0, canvas.Y1-canvas.Y0
+	if b := img.Bounds(); b.Dx() != width || b.Dy() != height {
+		return fmt.Errorf("the image is %vx%v pixels but the flat canvas of the TSIG is %vx%v pixels", b.Dx(), b.Dy(), width, height)
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"image"
+	"reflect"
+	"testing"
+
+	"github.com/mrmxf/opentsg-modules/opentsg-core/gridgen"
+)
+
+func TestPhysicalTiles(t *testing.T) {
+
+	tile := func(name string, at int, tags ...string) gridgen.Tilelayout {
+		return gridgen.Tilelayout{Name: name, Tags: tags, Layout: gridgen.Positions{Flat: gridgen.XY{X: at, Y: at}, Size: gridgen.XY{X: 10, Y: 5}}}
+	}
+
+	for _, tc := range []struct {
+		name  string
+		tiles []gridgen.Tilelayout
+		// want is the strips of each physical tile, in order
+		want   map[string][]string
+		order  []string
+		bounds map[string]image.Rectangle
+	}{
+		{name: "whole tiles",
+			tiles:  []gridgen.Tilelayout{tile("a", 0), tile("b", 10)},
+			want:   map[string][]string{"a": {"a"}, "b": {"b"}},
+			order:  []string{"a", "b"},
+			bounds: map[string]image.Rectangle{"a": image.Rect(0, 0, 10, 5), "b": image.Rect(10, 10, 20, 15)}},
+		{name: "strips are grouped and sorted down the canvas",
+			tiles:  []gridgen.Tilelayout{tile("t/s1", 5, "tile:t"), tile("u", 20), tile("t/s0", 0, "tile:t"), tile("t/s2", 10, "tile:t")},
+			want:   map[string][]string{"t": {"t/s0", "t/s1", "t/s2"}, "u": {"u"}},
+			order:  []string{"t", "u"},
+			bounds: map[string]image.Rectangle{"t": image.Rect(0, 0, 20, 15), "u": image.Rect(20, 20, 30, 25)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tiles := physicalTiles(gridgen.TPIG{Tilelayout: tc.tiles})
+
+			order := []string{}
+			for _, p := range tiles {
+				order = append(order, p.Name)
+
+				strips := []string{}
+				for _, s := range p.Strips {
+					strips = append(strips, s.Name)
+				}
+				if !reflect.DeepEqual(strips, tc.want[p.Name]) {
+					t.Errorf("%s has the strips %v, want %v", p.Name, strips, tc.want[p.Name])
+				}
+
+				if b := p.bounds(); b != tc.bounds[p.Name] {
+					t.Errorf("%s is bounded by %v, want %v", p.Name, b, tc.bounds[p.Name])
+				}
+			}
+
+			if !reflect.DeepEqual(order, tc.order) {
+				t.Errorf("got the tiles %v, want %v", order, tc.order)
+			}
+		})
+	}
+}
@@ -6,10 +6,10 @@
 package shapes
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"math"
+	"sort"
 
 	"github.com/mrmxf/opentsg-modules/opentsg-core/gridgen"
 )
@@ -33,9 +33,24 @@ type SphereCap struct {
 	// the azimuth angle in radians, follows the same rules as the inclination
 	// but tops at out pi radians.
 	AzimuthMaxAngle float64 `json:"azimuthMaxAngle" yaml:"azimuthMaxAngle"`
+	// the start and end inclination angles in radians, measured from the equator
+	// with positive angles above it. e.g. -10 and 60 degrees for a cap
+	// that is 10 degrees below and 60 degrees above the equator.
+	// They override the ThetaMaxAngle if either is set.
+	ThetaStartAngle float64 `json:"thetaStartAngle" yaml:"thetaStartAngle"`
+	ThetaEndAngle   float64 `json:"thetaEndAngle" yaml:"thetaEndAngle"`
+	// the start and end azimuth angles in radians, measured from the zero azimuth
+	// with positive angles anticlockwise. e.g. -40 and 70 degrees.
+	// They override the AzimuthMaxAngle if either is set.
+	AzimuthStartAngle float64 `json:"azimuthStartAngle" yaml:"azimuthStartAngle"`
+	AzimuthEndAngle   float64 `json:"azimuthEndAngle" yaml:"azimuthEndAngle"`
 	// pixels in each direction of the tile
 	Dx float64 `json:"dx" yaml:"dx"`
 	Dy float64 `json:"dy" yaml:"dy"`
+	// Orientation is "concave" for caps viewed from the inside, or "convex"
+	// for caps viewed from the outside. The face winding and uv map are
+	// set so the TSIG reads left to right from the viewer's side.
+	Orientation string `json:"orientation" yaml:"orientation"`
 	// shape name of "spherecap"
 	ShapeName
 }
@@ -45,93 +60,94 @@ func (s SphereCap) ObjType() string {
 	return "spherecap"
 }
 
+// Generate generates a TSIG and OBJ for a spherical cap.
+func (s SphereCap) Generate(wObj, wTsig io.Writer) error {
+	return generate(s, wObj, wTsig)
+}
+
 /*
-GenSphereOBJSquare generates a sphere made of tiles of size height and width.
+Build builds the model of a sphere made of tiles of size height and width.
 
 This works by splitting each row of pixels into their own tile, so the uv map matches exactly.
 
+The uv map is read from the inside, unless an orientation is given.
+
 All angles are in radians
 */
-func (s SphereCap) Generate(wObj, wTsig io.Writer) error {
+func (s SphereCap) Build() (*Model, error) {
+
+	if err := orientationFence(s.Orientation); err != nil {
+		return nil, err
+	}
+
+	// the top anticlockwise and bottom clockwise faces are wound outwards,
+	// the other two quarters are wound inwards. Flip the faces that do not
+	// match the orientation, and mirror the uv map for convex caps
+	// as it is read from the inside.
+	flipOut := s.Orientation == OrientationConcave
+	flipIn := s.Orientation == OrientationConvex
+	mirror := s.Orientation == OrientationConvex
+	mu := func(u float64) float64 {
+		if mirror {
+			return 1 - u
+		}
+		return u
+	}
+
+	thetaBelow, thetaAbove, azimuthClock, azimuthAnti, err := s.extents()
+	if err != nil {
+		return nil, err
+	}
 
 	// get the start point
 	azimuth, clockAz := 0.0, 0.0
 	// tileCount := 0
 	theta := math.Pi / 2
-	vertexCount := 1
 
 	thetaInc := 2 * (math.Asin(s.TileHeight / (2 * s.Radius)))
 	azimuthInc := (2 * math.Asin(s.TileWidth/(2*s.Radius)))
 	theta = (math.Pi / 2)
 
-	//
-	uTileWidth := 1 / (2 * math.Ceil(s.AzimuthMaxAngle/azimuthInc))
-	vTileHeight := 1 / (2 * math.Ceil(s.ThetaMaxAngle/thetaInc))
-
-	maxX := 2 * math.Ceil(s.AzimuthMaxAngle/azimuthInc) * s.Dx
-	maxY := 2 * math.Ceil(s.ThetaMaxAngle/thetaInc) * s.Dy
-
-	// calculate the overrun by looping through a segment of the
-	// sphere cap and seeing if the u value exceeds the regular bounds of
-	// 1. Due to the pixel shifting that happens
-	overrun := 1.0
-	for theta > (math.Pi/2)-s.ThetaMaxAngle {
-		topLeftTheta := theta - thetaInc
-		azimuth := 0.0
-
-		azimuthInc := (2 * math.Asin(s.TileWidth/(2*s.Radius))) / math.Sin(theta)
-		azimuthIncTop := (2 * math.Asin(s.TileWidth/(2*s.Radius))) / math.Sin(topLeftTheta)
-
-		// futDif is the length chordal length difference of the azimuth change on the bottom row.
-		// which is the closest current approximation
-		futDif := 2 * s.Radius * (math.Sin((azimuthIncTop-azimuthInc)/2) * math.Sin(theta))
-
-		// find the difference in pixels at the bottom and at the top
-		shift := int((futDif)/(s.TileWidth/s.Dx)) / 2
-		ushift := (float64(shift)) * (1.0 / float64(maxX))
-
-		//fmt.Println(futDif, 2*sphereRadius*(math.Sin((azimuthIncTop-azimuthInc)/2)*math.Sin(theta)), shift)
-		uTop := 0.5
-		for azimuth < s.AzimuthMaxAngle {
-			azimuth += azimuthIncTop
-
-			uTop += uTileWidth + (ushift * 2)
-
-		}
-
-		if uTop > overrun {
-			overrun = uTop
-		}
+	// tile counts either side of the zero azimuth and the equator
+	antiColumns, clockColumns := math.Ceil(azimuthAnti/azimuthInc), math.Ceil(azimuthClock/azimuthInc)
+	aboveRows, belowRows := math.Ceil(thetaAbove/thetaInc), math.Ceil(thetaBelow/thetaInc)
 
-		theta -= thetaInc
-	}
+	vTileHeight := 1 / (aboveRows + belowRows)
 
-	// reset theta back to what it was
-	theta = math.Pi / 2
+	maxX := (antiColumns + clockColumns) * s.Dx
+	maxY := (aboveRows + belowRows) * s.Dy
 
-	xInc := 0.0
-	if overrun > 1.0 {
-		xInc = math.Round((overrun - 1) * maxX)
-	}
+	// calculate the overrun of each side, the bottom rows mirror
+	// the top rows so the largest inclination is used.
+	thetaOverrun := math.Max(thetaAbove, thetaBelow)
+	xIncAnti := s.overrun(thetaOverrun, azimuthAnti, antiColumns)
+	xIncClock := s.overrun(thetaOverrun, azimuthClock, clockColumns)
 
 	// update the parameters to account for the overrun
 	// of tiles, if present
-	maxX = maxX + xInc*2
-	uTileWidth = s.Dx / maxX
+	maxX = maxX + xIncAnti + xIncClock
+	uTileWidth := s.Dx / maxX
+
+	// the uv position of the zero azimuth and the equator
+	uCentre := (clockColumns*s.Dx + xIncClock) / maxX
+	vCentre := belowRows / (aboveRows + belowRows)
 
-	tiles := []gridgen.Tilelayout{}
+	tiles := []ModelTile{}
+	// physical is the index of the final strip of each physical tile
+	physical := []int{}
+	topRow, botRow := 0, 0
 
 	// TOP
-	v := 0.5
+	v := vCentre
 
-	for theta > (math.Pi/2)-s.ThetaMaxAngle {
+	for theta > (math.Pi/2)-thetaAbove {
 		//start Point :=
 		topLeftThet := theta - thetaInc
 		topLeftAz := azimuth
 		// botLeftAz := azimuth
 
-		u := 0.5
-		uBot := 0.5
+		u := uCentre
+		uBot := uCentre
 		//		prevUshift := 0.0
 
 		azimuthInc := (2 * math.Asin(s.TileWidth/(2*s.Radius))) / math.Sin(theta)
@@ -147,7 +163,7 @@ func (s SphereCap) Generate(wObj, wTsig io.Writer) error {
 		//fmt.Println(futDif, 2*sphereRadius*(math.Sin((azimuthIncTop-azimuthInc)/2)*math.Sin(theta)), shift)
 		radialInc := 0
 
-		for azimuth < s.AzimuthMaxAngle {
+		for azimuth < azimuthAnti {
 			//	tileCount++
 
 			/*
@@ -177,7 +193,6 @@ func (s SphereCap) Generate(wObj, wTsig io.Writer) error {
 			vstep := float64(step) / maxY //(vheight / float64(shift+1))
 			ustep := (1.0 / float64(maxX))
 
-			tileFaces := ""
 			for i := 0; i < shift; i++ {
 
 				//	fmt.Println(x1, y1, x2, z2)
@@ -187,54 +202,31 @@ func (s SphereCap) Generate(wObj, wTsig io.Writer) error {
 				pos := shift - i
 				offset := float64((pos))*ustep + float64(radialInc*pos)*ustep
 
-				tileFaces += fmt.Sprintf("v %v %v %v\n", botX, botY, botZ)
-				tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uBot+offset), v+(float64(i)*vstep))
-
-				tileFaces += fmt.Sprintf("v %v %v %v \n", botRX, botRY, botRZ)
-				tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uTileWidth+uBot+offset), v+(float64(i)*vstep))
-
-				tileFaces += fmt.Sprintf("v %v %v %v \n", topRX, topRY, topRZ)
-				tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uTileWidth+uBot+offset), v+(float64(i+1)*vstep))
+				uvs := [4][2]float64{{mu(1 - (uBot + offset)), v + (float64(i) * vstep)}, {mu(1 - (uTileWidth + uBot + offset)), v + (float64(i) * vstep)}, {mu(1 - (uTileWidth + uBot + offset)), v + (float64(i+1) * vstep)}, {mu(1 - (uBot + offset)), v + (float64(i+1) * vstep)}}
 
-				tileFaces += fmt.Sprintf("v %v %v %v \n", topX, topY, topZ)
-				tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uBot+offset), v+(float64(i+1)*vstep))
-				tileFaces += fmt.Sprintf("f %v/%v %v/%v %v/%v %v/%v\n", vertexCount, vertexCount, vertexCount+1, vertexCount+1, vertexCount+2, vertexCount+2, vertexCount+3, vertexCount+3)
-
-				tiles = append(tiles, gridgen.Tilelayout{Layout: gridgen.Positions{
+				// the normals are along the radius of the sphere
+				corners := [4][3]float64{{botX, botY, botZ}, {botRX, botRY, botRZ}, {topRX, topRY, topRZ}, {topX, topY, topZ}}
+				tiles = append(tiles, ModelTile{Row: topRow, Strip: stripNumber(i+1, shift), Corners: corners, UVs: uvs, Normals: corners, Flip: flipOut,
 					Flat: gridgen.XY{X: int((1 - (uBot + uTileWidth + offset)) * maxX), Y: int(math.Round((1 - (v + (float64(i+1) * vstep))) * maxY))},
-					Size: gridgen.XY{X: int(s.Dx), Y: int(maxY * vstep)}}})
+					Size: gridgen.XY{X: int(s.Dx), Y: int(maxY * vstep)}})
 
 				botX, botY, botZ = topX, topY, topZ
 				botRX, botRY, botRZ = topRX, topRY, topRZ
-				vertexCount += 4
 			}
 
 			// the max v picks off from the last one to accoount for rounding errors
-			tileFaces += fmt.Sprintf("v %v %v %v \n", botX, botY, botZ)
-			tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uBot), v+(vstep*(float64(shift))))
-
-			tileFaces += fmt.Sprintf("v %v %v %v \n", botRX, botRY, botRZ)
-			tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uTileWidth+uBot), v+(vstep*(float64(shift))))
-
-			tileFaces += fmt.Sprintf("v %v %v %v \n", x3, y3, z3)
-			tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uTileWidth+uBot), v+vTileHeight)
 
-			tileFaces += fmt.Sprintf("v %v %v %v \n", x4, y4, z4)
-			tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uBot), v+vTileHeight)
+			uvs := [4][2]float64{{mu(1 - (uBot)), v + (vstep * (float64(shift)))}, {mu(1 - (uTileWidth + uBot)), v + (vstep * (float64(shift)))}, {mu(1 - (uTileWidth + uBot)), v + vTileHeight}, {mu(1 - (uBot)), v + vTileHeight}}
 
-			tiles = append(tiles, gridgen.Tilelayout{Layout: gridgen.Positions{
+			// the normals are along the radius of the sphere
+			corners := [4][3]float64{{botX, botY, botZ}, {botRX, botRY, botRZ}, {x3, y3, z3}, {x4, y4, z4}}
+			tiles = append(tiles, ModelTile{Row: topRow, Strip: stripNumber(shift+1, shift), Corners: corners, UVs: uvs, Normals: corners, Flip: flipOut,
 				Flat: gridgen.XY{X: int((1 - (uBot + uTileWidth)) * maxX), Y: int(math.Round((1 - (v + vTileHeight)) * maxY))},
-				Size: gridgen.XY{X: int(s.Dx), Y: int(math.Round(maxY * (vTileHeight - vstep*(float64(shift)))))}}})
+				Size: gridgen.XY{X: int(s.Dx), Y: int(math.Round(maxY * (vTileHeight - vstep*(float64(shift)))))}})
+			physical = append(physical, len(tiles)-1)
 
 			// radialInc++
 
-			tileFaces += fmt.Sprintf("f %v/%v %v/%v %v/%v %v/%v\n", vertexCount, vertexCount, vertexCount+1, vertexCount+1, vertexCount+2, vertexCount+2, vertexCount+3, vertexCount+3)
-
-			_, err := wObj.Write([]byte(tileFaces))
-			if err != nil {
-				return fmt.Errorf("error writing to obj %v", err)
-			}
-
 			// nlX, nlY, nlZ := PolarToCartesian(sphereRadius, topLeftThet+thetaInc, topLeftAz+azimuthIncTop)
 
 			//			fmt.Println("4", 1-(u), "3", 1-(u+uWidth))
@@ -243,18 +235,17 @@ func (s SphereCap) Generate(wObj, wTsig io.Writer) error {
 			uBot += uTileWidth
 			azimuth += azimuthIncTop
 			topLeftAz = azimuth
-			vertexCount += 4
 			u += uTileWidth
 			radialInc += 2
 
 		}
 
 		topRightAz := clockAz
-		u = 0.5
-		uBot = 0.5
+		u = uCentre
+		uBot = uCentre
 
 		radialInc = 0
-		for clockAz > -s.ThetaMaxAngle {
+		for clockAz > -azimuthClock {
 
 			x1, y1, z1 := PolarToCartesian(s.Radius, topLeftThet+thetaInc, topRightAz)
 			x2, y2, z2 := PolarToCartesian(s.Radius, topLeftThet+thetaInc, topRightAz-azimuthInc)
@@ -271,7 +262,6 @@ func (s SphereCap) Generate(wObj, wTsig io.Writer) error {
 			//	vstep := float64(step) * (1.0 / float64(maxY))
 			vstep := float64(step) / maxY //(vheight / float64(shift+1))
 			ustep := (1.0 / float64(maxX))
-			tileFaces := ""
 			for i := 0; i < shift; i++ {
 
 				//	fmt.Println(x1, y1, x2, z2)
@@ -282,55 +272,32 @@ func (s SphereCap) Generate(wObj, wTsig io.Writer) error {
 				pos := shift - i
 				stepOffset := -float64((pos))*ustep - float64(radialInc*pos)*ustep
 
-				tileFaces += fmt.Sprintf("v %v %v %v \n", botX, botY, botZ)
-				tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uBot+stepOffset), v+(float64(i)*vstep))
-
-				tileFaces += fmt.Sprintf("v %v %v %v \n", botRX, botRY, botRZ)
-				tileFaces += fmt.Sprintf("vt %v %v \n", 1-(-uTileWidth+uBot+stepOffset), v+(float64(i)*vstep))
-
-				tileFaces += fmt.Sprintf("v %v %v %v \n", topRX, topRY, topRZ)
-				tileFaces += fmt.Sprintf("vt %v %v \n", 1-(-uTileWidth+uBot+stepOffset), v+(float64(i+1)*vstep))
-
-				tileFaces += fmt.Sprintf("v %v %v %v \n", topX, topY, topZ)
-				tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uBot+stepOffset), v+(float64(i+1)*vstep))
-				tileFaces += fmt.Sprintf("f %v/%v %v/%v %v/%v %v/%v\n", vertexCount, vertexCount, vertexCount+1, vertexCount+1, vertexCount+2, vertexCount+2, vertexCount+3, vertexCount+3)
+				uvs := [4][2]float64{{mu(1 - (uBot + stepOffset)), v + (float64(i) * vstep)}, {mu(1 - (-uTileWidth + uBot + stepOffset)), v + (float64(i) * vstep)}, {mu(1 - (-uTileWidth + uBot + stepOffset)), v + (float64(i+1) * vstep)}, {mu(1 - (uBot + stepOffset)), v + (float64(i+1) * vstep)}}
 
-				tiles = append(tiles, gridgen.Tilelayout{Layout: gridgen.Positions{
+				// the normals are along the radius of the sphere
+				corners := [4][3]float64{{botX, botY, botZ}, {botRX, botRY, botRZ}, {topRX, topRY, topRZ}, {topX, topY, topZ}}
+				tiles = append(tiles, ModelTile{Row: topRow, Strip: stripNumber(i+1, shift), Corners: corners, UVs: uvs, Normals: corners, Flip: flipIn,
 					Flat: gridgen.XY{X: int((1 - (uBot + stepOffset)) * maxX), Y: int(math.Round((1 - (v + (float64(i+1) * vstep))) * maxY))},
-					Size: gridgen.XY{X: int(s.Dx), Y: int(maxY * vstep)}}})
+					Size: gridgen.XY{X: int(s.Dx), Y: int(maxY * vstep)}})
 
 				botX, botY, botZ = topX, topY, topZ
 				botRX, botRY, botRZ = topRX, topRY, topRZ
-				vertexCount += 4
 			}
 
 			// write the final tile, which may be the only one
-			tileFaces += fmt.Sprintf("v %v %v %v \n", botX, botY, botZ)
-			tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uBot), v+(vstep*(float64(shift))))
 
-			tileFaces += fmt.Sprintf("v %v %v %v \n", botRX, botRY, botRZ)
-			tileFaces += fmt.Sprintf("vt %v %v \n", 1-(-uTileWidth+uBot), v+(vstep*(float64(shift))))
+			uvs := [4][2]float64{{mu(1 - (uBot)), v + (vstep * (float64(shift)))}, {mu(1 - (-uTileWidth + uBot)), v + (vstep * (float64(shift)))}, {mu(1 - (-uTileWidth + uBot)), v + vTileHeight}, {mu(1 - (uBot)), v + vTileHeight}}
 
-			tileFaces += fmt.Sprintf("v %v %v %v \n", x3, y3, z3)
-			tileFaces += fmt.Sprintf("vt %v %v \n", 1-(-uTileWidth+uBot), v+vTileHeight)
-
-			tileFaces += fmt.Sprintf("v %v %v %v \n", x4, y4, z4)
-			tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uBot), v+vTileHeight)
-
-			tiles = append(tiles, gridgen.Tilelayout{Layout: gridgen.Positions{
+			// the normals are along the radius of the sphere
+			corners := [4][3]float64{{botX, botY, botZ}, {botRX, botRY, botRZ}, {x3, y3, z3}, {x4, y4, z4}}
+			tiles = append(tiles, ModelTile{Row: topRow, Strip: stripNumber(shift+1, shift), Corners: corners, UVs: uvs, Normals: corners, Flip: flipIn,
 				Flat: gridgen.XY{X: int((1 - uBot) * maxX), Y: int(math.Round((1 - (v + vTileHeight)) * maxY))},
-				Size: gridgen.XY{X: int(s.Dx), Y: int(math.Round(maxY * (vTileHeight - vstep*(float64(shift)))))}}})
-
-			tileFaces += fmt.Sprintf("f %v/%v %v/%v %v/%v %v/%v\n", vertexCount, vertexCount, vertexCount+1, vertexCount+1, vertexCount+2, vertexCount+2, vertexCount+3, vertexCount+3)
+				Size: gridgen.XY{X: int(s.Dx), Y: int(math.Round(maxY * (vTileHeight - vstep*(float64(shift)))))}})
+			physical = append(physical, len(tiles)-1)
 
-			_, err := wObj.Write([]byte(tileFaces))
-			if err != nil {
-				return fmt.Errorf("error writing to obj %v", err)
-			}
 			//	objbuf.WriteString(fmt.Sprintf("f %v/%v %v/%v %v/%v %v/%v\n", count, count, count+1, count+1, count+2, count+2, count+3, count+3))
 			clockAz -= azimuthIncTop
 			topRightAz = clockAz
-			vertexCount += 4
 			u -= uTileWidth
 			radialInc += 2
 			uBot -= (uTileWidth)
@@ -341,20 +308,21 @@ func (s SphereCap) Generate(wObj, wTsig io.Writer) error {
 		azimuth = 0
 		clockAz = 0
 		v += vTileHeight
+		topRow++
 		//fmt.Println("COINTER", theta, z, zinchold)
 		//	z = zinchold
 
 	}
 
 	// Bottom
-	v = 0.5
+	v = vCentre
 	theta = math.Pi / 2
-	for theta < (math.Pi/2)+s.ThetaMaxAngle {
+	for theta < (math.Pi/2)+thetaBelow {
 		//start Point :=
 		botLeftThet := theta + thetaInc
 		botLeftAz := azimuth
-		u := 0.5
-		uTop := 0.5
+		u := uCentre
+		uTop := uCentre
 
 		azimuthInc := (2 * math.Asin(s.TileWidth/(2*s.Radius))) / math.Sin(theta)
 		azimuthIncBot := (2 * math.Asin(s.TileWidth/(2*s.Radius))) / math.Sin(botLeftThet)
@@ -365,7 +333,7 @@ func (s SphereCap) Generate(wObj, wTsig io.Writer) error {
 
 		radialInc := 0
 
-		for azimuth < s.ThetaMaxAngle {
+		for azimuth < azimuthAnti {
 
 			// tileCount++
 			x1, y1, z1 := PolarToCartesian(s.Radius, botLeftThet-thetaInc, botLeftAz)
@@ -384,64 +352,38 @@ func (s SphereCap) Generate(wObj, wTsig io.Writer) error {
 			vstep := float64(step) / maxY // (vheight / float64(shift+1))
 			ustep := (1.0 / float64(maxX))
 
-			tileFaces := ""
 			for i := 0; i < shift; i++ {
 
 				botX, botY, botZ := topX+leftVectX, topY+leftVectY, topZ+leftVectZ
 				botRX, botRY, botRZ := topRX+rightVectX, topRY+rightVectY, topRZ+rightVectZ
 				pos := shift - i
 
-				tileFaces += fmt.Sprintf("v %v %v %v \n", topX, topY, topZ)
-				tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uTop+float64((pos))*ustep+float64(radialInc*pos)*ustep), v-float64(i)*vstep)
+				uvs := [4][2]float64{{mu(1 - (uTop + float64((pos))*ustep + float64(radialInc*pos)*ustep)), v - float64(i)*vstep}, {mu(1 - (uTop + uTileWidth + float64((pos))*ustep + float64(radialInc*pos)*ustep)), v - float64(i)*vstep}, {mu(1 - (uTop + uTileWidth + float64((pos))*ustep + float64(radialInc*pos)*ustep)), v - float64(i+1)*vstep}, {mu(1 - (uTop + float64((pos))*ustep + float64(radialInc*pos)*ustep)), v - float64(i+1)*vstep}}
 
-				tileFaces += fmt.Sprintf("v %v %v %v \n", topRX, topRY, topRZ)
-				tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uTop+uTileWidth+float64((pos))*ustep+float64(radialInc*pos)*ustep), v-float64(i)*vstep)
-
-				tileFaces += fmt.Sprintf("v %v %v %v \n", botRX, botRY, botRZ)
-				tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uTop+uTileWidth+float64((pos))*ustep+float64(radialInc*pos)*ustep), v-float64(i+1)*vstep)
-
-				tileFaces += fmt.Sprintf("v %v %v %v \n", botX, botY, botZ)
-				tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uTop+float64((pos))*ustep+float64(radialInc*pos)*ustep), v-float64(i+1)*vstep)
-				tileFaces += fmt.Sprintf("f %v/%v %v/%v %v/%v %v/%v\n", vertexCount, vertexCount, vertexCount+1, vertexCount+1, vertexCount+2, vertexCount+2, vertexCount+3, vertexCount+3)
-
-				tiles = append(tiles, gridgen.Tilelayout{Layout: gridgen.Positions{
+				// the normals are along the radius of the sphere
+				corners := [4][3]float64{{topX, topY, topZ}, {topRX, topRY, topRZ}, {botRX, botRY, botRZ}, {botX, botY, botZ}}
+				tiles = append(tiles, ModelTile{Row: -1 - botRow, Strip: stripNumber(shift+1-i, shift), Corners: corners, UVs: uvs, Normals: corners, Flip: flipIn,
 					Flat: gridgen.XY{X: int((1 - (uTop + uTileWidth + float64((pos))*ustep + float64(radialInc*pos)*ustep)) * maxX), Y: int(math.Round((1 - (v - (float64(i) * vstep))) * maxY))},
-					Size: gridgen.XY{X: int(s.Dx), Y: int(maxY * vstep)}}})
+					Size: gridgen.XY{X: int(s.Dx), Y: int(maxY * vstep)}})
 
 				topX, topY, topZ = botX, botY, botZ
 				topRX, topRY, topRZ = botRX, botRY, botRZ
-				vertexCount += 4
 			}
 
-			tileFaces += fmt.Sprintf("v %v %v %v \n", topX, topY, topZ)
-			tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uTop), v-float64(shift)*vstep)
-
-			tileFaces += fmt.Sprintf("v %v %v %v \n", topRX, topRY, topRZ)
-			tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uTop+uTileWidth), v-float64(shift)*vstep)
+			uvs := [4][2]float64{{mu(1 - (uTop)), v - float64(shift)*vstep}, {mu(1 - (uTop + uTileWidth)), v - float64(shift)*vstep}, {mu(1 - (uTop + uTileWidth)), v - vTileHeight}, {mu(1 - (uTop)), v - vTileHeight}}
 
-			tileFaces += fmt.Sprintf("v %v %v %v \n", x3, y3, z3)
-			tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uTop+uTileWidth), v-vTileHeight)
-
-			tileFaces += fmt.Sprintf("v %v %v %v \n", x4, y4, z4)
-			tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uTop), v-vTileHeight)
-
-			tiles = append(tiles, gridgen.Tilelayout{Layout: gridgen.Positions{
+			// the normals are along the radius of the sphere
+			corners := [4][3]float64{{topX, topY, topZ}, {topRX, topRY, topRZ}, {x3, y3, z3}, {x4, y4, z4}}
+			tiles = append(tiles, ModelTile{Row: -1 - botRow, Strip: stripNumber(1, shift), Corners: corners, UVs: uvs, Normals: corners, Flip: flipIn,
 				Flat: gridgen.XY{X: int((1 - (uTop + uTileWidth)) * maxX), Y: int(math.Round((1 - (v - float64(shift)*vstep)) * maxY))},
-				Size: gridgen.XY{X: int(s.Dx), Y: int(math.Round(maxY * (vTileHeight - vstep*(float64(shift)))))}}})
+				Size: gridgen.XY{X: int(s.Dx), Y: int(math.Round(maxY * (vTileHeight - vstep*(float64(shift)))))}})
+			physical = append(physical, len(tiles)-1)
 
 			//	fmt.Println(math.Sqrt(math.Pow((x2)-x1, 2)+math.Pow((y2)-y1, 2)) + math.Pow((z2)-z1, 2))
 
-			tileFaces += fmt.Sprintf("f %v/%v %v/%v %v/%v %v/%v\n", vertexCount, vertexCount, vertexCount+1, vertexCount+1, vertexCount+2, vertexCount+2, vertexCount+3, vertexCount+3)
-
-			_, err := wObj.Write([]byte(tileFaces))
-			if err != nil {
-				return fmt.Errorf("error writing to obj %v", err)
-			}
-
 			//	objbuf.WriteString(fmt.Sprintf("f %v/%v %v/%v %v/%v %v/%v\n", count, count, count+1, count+1, count+2, count+2, count+3, count+3))
 			azimuth += azimuthIncBot
 			botLeftAz = azimuth
-			vertexCount += 4
 			u += uTileWidth
 			// uTop += uWidth + (ushift * 2)'
 			uTop += uTileWidth //+ ushift
@@ -449,10 +391,10 @@ func (s SphereCap) Generate(wObj, wTsig io.Writer) error {
 		}
 
 		botRightAz := clockAz
-		u = 0.5
-		uTop = 0.5
+		u = uCentre
+		uTop = uCentre
 		radialInc = 0
-		for clockAz > -s.ThetaMaxAngle {
+		for clockAz > -azimuthClock {
 
 			azimuthInc := (2 * math.Asin(s.TileWidth/(2*s.Radius))) / math.Sin(theta)
 			azimuthIncTop := (2 * math.Asin(s.TileWidth/(2*s.Radius))) / math.Sin(botLeftThet)
@@ -472,7 +414,6 @@ func (s SphereCap) Generate(wObj, wTsig io.Writer) error {
 			vstep := float64(step) / maxY // (vheight / float64(shift+1))
 			ustep := (-1.0 / float64(maxX))
 
-			tileFaces := ""
 			for i := 0; i < shift; i++ {
 
 				//	fmt.Println(x1, y1, x2, z2)
@@ -481,43 +422,26 @@ func (s SphereCap) Generate(wObj, wTsig io.Writer) error {
 				botRX, botRY, botRZ := topRX+rightVectX, topRY+rightVectY, topRZ+rightVectZ
 				pos := shift - i
 
-				tileFaces += fmt.Sprintf("v %v %v %v \n", topX, topY, topZ)
-				tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uTop+float64((pos))*ustep+float64(radialInc*pos)*ustep), v-float64(i)*vstep)
+				uvs := [4][2]float64{{mu(1 - (uTop + float64((pos))*ustep + float64(radialInc*pos)*ustep)), v - float64(i)*vstep}, {mu(1 - (uTop - uTileWidth + float64((pos))*ustep + float64(radialInc*pos)*ustep)), v - float64(i)*vstep}, {mu(1 - (uTop - uTileWidth + float64((pos))*ustep + float64(radialInc*pos)*ustep)), v - float64(i+1)*vstep}, {mu(1 - (uTop + float64((pos))*ustep + float64(radialInc*pos)*ustep)), v - float64(i+1)*vstep}}
 
-				tileFaces += fmt.Sprintf("v %v %v %v \n", topRX, topRY, topRZ)
-				tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uTop-uTileWidth+float64((pos))*ustep+float64(radialInc*pos)*ustep), v-float64(i)*vstep)
-
-				tileFaces += fmt.Sprintf("v %v %v %v \n", botRX, botRY, botRZ)
-				tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uTop-uTileWidth+float64((pos))*ustep+float64(radialInc*pos)*ustep), v-float64(i+1)*vstep)
-
-				tileFaces += fmt.Sprintf("v %v %v %v \n", botX, botY, botZ)
-				tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uTop+float64((pos))*ustep+float64(radialInc*pos)*ustep), v-float64(i+1)*vstep)
-				tileFaces += fmt.Sprintf("f %v/%v %v/%v %v/%v %v/%v\n", vertexCount, vertexCount, vertexCount+1, vertexCount+1, vertexCount+2, vertexCount+2, vertexCount+3, vertexCount+3)
-
-				tiles = append(tiles, gridgen.Tilelayout{Layout: gridgen.Positions{
+				// the normals are along the radius of the sphere
+				corners := [4][3]float64{{topX, topY, topZ}, {topRX, topRY, topRZ}, {botRX, botRY, botRZ}, {botX, botY, botZ}}
+				tiles = append(tiles, ModelTile{Row: -1 - botRow, Strip: stripNumber(shift+1-i, shift), Corners: corners, UVs: uvs, Normals: corners, Flip: flipOut,
 					Flat: gridgen.XY{X: int((1 - (uTop + float64((pos))*ustep + float64(radialInc*pos)*ustep)) * maxX), Y: int(math.Round((1 - (v - (float64(i) * vstep))) * maxY))},
-					Size: gridgen.XY{X: int(s.Dx), Y: int(maxY * vstep)}}})
+					Size: gridgen.XY{X: int(s.Dx), Y: int(maxY * vstep)}})
 
 				topX, topY, topZ = botX, botY, botZ
 				topRX, topRY, topRZ = botRX, botRY, botRZ
-				vertexCount += 4
 			}
 
-			tileFaces += fmt.Sprintf("v %v %v %v \n", topX, topY, topZ)
-			tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uTop), v-float64(shift)*vstep)
-
-			tileFaces += fmt.Sprintf("v %v %v %v \n", topRX, topRY, topRZ)
-			tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uTop-uTileWidth), v-float64(shift)*vstep)
+			uvs := [4][2]float64{{mu(1 - (uTop)), v - float64(shift)*vstep}, {mu(1 - (uTop - uTileWidth)), v - float64(shift)*vstep}, {mu(1 - (uTop - uTileWidth)), v - vTileHeight}, {mu(1 - (uTop)), v - vTileHeight}}
 
-			tileFaces += fmt.Sprintf("v %v %v %v \n", x3, y3, z3)
-			tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uTop-uTileWidth), v-vTileHeight)
-
-			tileFaces += fmt.Sprintf("v %v %v %v \n", x4, y4, z4)
-			tileFaces += fmt.Sprintf("vt %v %v \n", 1-(uTop), v-vTileHeight)
-
-			tiles = append(tiles, gridgen.Tilelayout{Layout: gridgen.Positions{
+			// the normals are along the radius of the sphere
+			corners := [4][3]float64{{topX, topY, topZ}, {topRX, topRY, topRZ}, {x3, y3, z3}, {x4, y4, z4}}
+			tiles = append(tiles, ModelTile{Row: -1 - botRow, Strip: stripNumber(1, shift), Corners: corners, UVs: uvs, Normals: corners, Flip: flipOut,
 				Flat: gridgen.XY{X: int((1 - uTop) * maxX), Y: int(math.Round((1 - (v - float64(shift)*vstep)) * maxY))},
-				Size: gridgen.XY{X: int(s.Dx), Y: int(math.Round(maxY * (vTileHeight - vstep*(float64(shift)))))}}})
+				Size: gridgen.XY{X: int(s.Dx), Y: int(math.Round(maxY * (vTileHeight - vstep*(float64(shift)))))}})
+			physical = append(physical, len(tiles)-1)
 			//	leftVectX, leftVectY, leftVectZ := (x4-x1)/dy, (y4-y1)/dy, (z4-z1)/dy
 			//	rightVectX, rightVectY, rightVectZ := (x3-x2)/dy, (y3-y2)/dy, (z3-z2)/dy
 			/*
@@ -528,16 +452,9 @@ func (s SphereCap) Generate(wObj, wTsig io.Writer) error {
 			*/
 			// +1 to rember the 0th line and get the correct amount of increments
 
-			tileFaces += fmt.Sprintf("f %v/%v %v/%v %v/%v %v/%v\n", vertexCount, vertexCount, vertexCount+1, vertexCount+1, vertexCount+2, vertexCount+2, vertexCount+3, vertexCount+3)
-
-			_, err := wObj.Write([]byte(tileFaces))
-			if err != nil {
-				return fmt.Errorf("error writing to obj %v", err)
-			}
 			//	objbuf.WriteString(fmt.Sprintf("f %v/%v %v/%v %v/%v %v/%v\n", count, count, count+1, count+1, count+2, count+2, count+3, count+3))
 			clockAz -= azimuthIncTop
 			botRightAz = clockAz
-			vertexCount += 4
 			radialInc += 2
 			u -= uTileWidth
 			uTop -= (uTileWidth) // + (ushift * 2))
@@ -547,15 +464,149 @@ func (s SphereCap) Generate(wObj, wTsig io.Writer) error {
 		theta += thetaInc
 		azimuth = 0
 		clockAz = 0
+		botRow++
 		//fmt.Println("COINTER", theta, z, zinchold)
 		//	z = zinchold
 
 	}
 
-	tsig := gridgen.TPIG{Tilelayout: tiles, Dimensions: gridgen.Dimensions{Flat: gridgen.XY2D{X0: 0, X1: int(maxX), Y0: 0, Y1: int(maxY)}}}
+	if mirror {
+		for i, t := range tiles {
+			tiles[i].Flat.X = int(maxX) - t.Flat.X - t.Size.X
+		}
+	}
+
+	sphereGrid(tiles, physical, botRow)
+
+	m := &Model{Shape: s.ObjType(), Tiles: tiles, Flat: gridgen.XY2D{X0: 0, X1: int(maxX), Y0: 0, Y1: int(maxY)}}
+	describeTiles(m)
+
+	return m, nil
+}
 
-	enc := json.NewEncoder(wTsig)
-	enc.SetIndent("", "    ")
+// extents returns the angles either side of the equator and the zero azimuth
+// the sphere cap covers. The start and end angles are used if either is set,
+// else the max angles are used in both directions.
+func (s SphereCap) extents() (thetaBelow, thetaAbove, azimuthClock, azimuthAnti float64, err error) {
 
-	return enc.Encode(tsig)
+	thetaBelow, thetaAbove = s.ThetaMaxAngle, s.ThetaMaxAngle
+	if s.ThetaStartAngle != 0 || s.ThetaEndAngle != 0 {
+		thetaBelow, thetaAbove = -s.ThetaStartAngle, s.ThetaEndAngle
+	}
+
+	azimuthClock, azimuthAnti = s.AzimuthMaxAngle, s.AzimuthMaxAngle
+	if s.AzimuthStartAngle != 0 || s.AzimuthEndAngle != 0 {
+		azimuthClock, azimuthAnti = -s.AzimuthStartAngle, s.AzimuthEndAngle
+	}
+
+	// the cap is built out from the equator and zero azimuth
+	// so they must be included.
+	if thetaBelow < 0 || thetaAbove < 0 || thetaBelow+thetaAbove == 0 {
+		return 0, 0, 0, 0, fmt.Errorf("the inclination angles of %v to %v must include the equator", -thetaBelow, thetaAbove)
+	}
+
+	if thetaBelow >= math.Pi/2 || thetaAbove >= math.Pi/2 {
+		return 0, 0, 0, 0, fmt.Errorf("the inclination angles of %v to %v must be less than pi/2 from the equator", -thetaBelow, thetaAbove)
+	}
+
+	if azimuthClock < 0 || azimuthAnti < 0 || azimuthClock+azimuthAnti == 0 {
+		return 0, 0, 0, 0, fmt.Errorf("the azimuth angles of %v to %v must include the zero azimuth", -azimuthClock, azimuthAnti)
+	}
+
+	if azimuthClock+azimuthAnti > 2*math.Pi {
+		return 0, 0, 0, 0, fmt.Errorf("the azimuth angles of %v to %v cover more than 2pi radians", -azimuthClock, azimuthAnti)
+	}
+
+	return thetaBelow, thetaAbove, azimuthClock, azimuthAnti, nil
+}
+
+// overrun calculates the extra pixels needed on one side of the zero azimuth.
+// It loops through the rows of that side of the sphere cap and
+// checks if the u value exceeds the regular bounds of 1, due to
+// the pixel shifting that happens.
+func (s SphereCap) overrun(thetaLimit, azimuthLimit, columns float64) float64 {
+
+	// use a uv map of this side mirrored, so the bounds
+	// are 0.5 to 1.
+	sideX := 2 * columns * s.Dx
+	uTileWidth := 1 / (2 * columns)
+
+	thetaInc := 2 * (math.Asin(s.TileHeight / (2 * s.Radius)))
+	theta := math.Pi / 2
+	overrun := 1.0
+	for theta > (math.Pi/2)-thetaLimit {
+		topLeftTheta := theta - thetaInc
+		azimuth := 0.0
+
+		azimuthInc := (2 * math.Asin(s.TileWidth/(2*s.Radius))) / math.Sin(theta)
+		azimuthIncTop := (2 * math.Asin(s.TileWidth/(2*s.Radius))) / math.Sin(topLeftTheta)
+
+		// futDif is the length chordal length difference of the azimuth change on the bottom row.
+		// which is the closest current approximation
+		futDif := 2 * s.Radius * (math.Sin((azimuthIncTop-azimuthInc)/2) * math.Sin(theta))
+
+		// find the difference in pixels at the bottom and at the top
+		shift := int((futDif)/(s.TileWidth/s.Dx)) / 2
+		ushift := (float64(shift)) * (1.0 / float64(sideX))
+
+		uTop := 0.5
+		for azimuth < azimuthLimit {
+			azimuth += azimuthIncTop
+
+			uTop += uTileWidth + (ushift * 2)
+		}
+
+		if uTop > overrun {
+			overrun = uTop
+		}
+
+		theta -= thetaInc
+	}
+
+	if overrun > 1.0 {
+		return math.Round((overrun - 1) * sideX)
+	}
+
+	return 0
+}
+
+// stripNumber returns the strip number of a tile,
+// or 0 if the tile has not been split into strips.
+func stripNumber(strip, shift int) int {
+	if shift == 0 {
+		return 0
+	}
+
+	return strip
+}
+
+// sphereGrid moves the rows to count from the bottom row, and sets the column of each
+// tile from the left of the flat layout. The strips of a tile share the row and
+// column of the whole tile.
+func sphereGrid(tiles []ModelTile, physical []int, bottomRows int) {
+
+	rows := map[int][]int{}
+	for _, p := range physical {
+		tiles[p].Row += bottomRows
+		rows[tiles[p].Row] = append(rows[tiles[p].Row], p)
+	}
+
+	for _, row := range rows {
+		sort.Slice(row, func(i, j int) bool {
+			return tiles[row[i]].Flat.X < tiles[row[j]].Flat.X
+		})
+
+		for col, p := range row {
+			tiles[p].Col = col
+		}
+	}
+
+	// the strips of a tile are before the final strip
+	start := 0
+	for _, p := range physical {
+		for i := start; i < p; i++ {
+			tiles[i].Row, tiles[i].Col = tiles[p].Row, tiles[p].Col
+		}
+		start = p + 1
+	}
 }
@@ -0,0 +1,128 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestSphereCapExtents(t *testing.T) {
+
+	for _, tc := range []struct {
+		name string
+		cap  SphereCap
+		// below and above the equator, clockwise and anticlockwise of the zero azimuth
+		extents [4]float64
+		err     string
+	}{
+		{name: "max angles", cap: SphereCap{ThetaMaxAngle: 0.5, AzimuthMaxAngle: 1},
+			extents: [4]float64{0.5, 0.5, 1, 1}},
+		{name: "start and end angles", cap: SphereCap{ThetaStartAngle: -0.2, ThetaEndAngle: 0.9, AzimuthStartAngle: -0.3, AzimuthEndAngle: 1.2},
+			extents: [4]float64{0.2, 0.9, 0.3, 1.2}},
+		{name: "start and end angles override the max angles", cap: SphereCap{ThetaMaxAngle: 0.5, AzimuthMaxAngle: 1, ThetaEndAngle: 0.4, AzimuthStartAngle: -0.6},
+			extents: [4]float64{0, 0.4, 0.6, 0}},
+		{name: "inclination above the equator", cap: SphereCap{ThetaStartAngle: 0.1, ThetaEndAngle: 0.5, AzimuthMaxAngle: 1},
+			err: "must include the equator"},
+		{name: "inclination at the pole", cap: SphereCap{ThetaStartAngle: -0.1, ThetaEndAngle: math.Pi / 2, AzimuthMaxAngle: 1},
+			err: "must be less than pi/2"},
+		{name: "azimuth beside the zero azimuth", cap: SphereCap{ThetaMaxAngle: 0.5, AzimuthStartAngle: 0.2, AzimuthEndAngle: 1},
+			err: "must include the zero azimuth"},
+		{name: "azimuth all the way round", cap: SphereCap{ThetaMaxAngle: 0.5, AzimuthStartAngle: -math.Pi, AzimuthEndAngle: math.Pi + 0.1},
+			err: "cover more than 2pi radians"},
+		{name: "no angles", cap: SphereCap{},
+			err: "must include the equator"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			below, above, clock, anti, err := tc.cap.extents()
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("got the error %v, want an error of %q", err, tc.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := [4]float64{below, above, clock, anti}; got != tc.extents {
+				t.Errorf("got the extents %v, want %v", got, tc.extents)
+			}
+		})
+	}
+}
+
+func TestSphereCapOverrun(t *testing.T) {
+
+	// the tiles are 0.1 radians across
+	tiles := SphereCap{TileHeight: 0.5, TileWidth: 0.5, Radius: 5, Dx: 100, Dy: 100}
+	columns := func(azimuth float64) float64 {
+		return math.Ceil(azimuth / (2 * math.Asin(tiles.TileWidth/(2*tiles.Radius))))
+	}
+
+	for _, tc := range []struct {
+		name           string
+		theta, azimuth float64
+		overrun        float64
+	}{
+		// the rows next to the equator are not shifted
+		{name: "one row", theta: 0.05, azimuth: 0.3},
+		{name: "narrow", theta: 0.5, azimuth: 0.3, overrun: 12},
+		{name: "wide", theta: 0.5, azimuth: 1.2, overrun: 24},
+		{name: "tall", theta: 0.9, azimuth: 0.3, overrun: 24},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tiles.overrun(tc.theta, tc.azimuth, columns(tc.azimuth)); got != tc.overrun {
+				t.Errorf("got an overrun of %v pixels, want %v", got, tc.overrun)
+			}
+		})
+	}
+}
+
+func TestSphereCapAsymmetric(t *testing.T) {
+
+	base := SphereCap{TileHeight: 0.5, TileWidth: 0.5, Radius: 5, Dx: 100, Dy: 100}
+
+	for _, tc := range []struct {
+		name   string
+		angles func(s *SphereCap)
+		// the canvas is the tiles either side of the zero azimuth,
+		// with the overrun of each side
+		width, height int
+	}{
+		{name: "max angles", angles: func(s *SphereCap) { s.ThetaMaxAngle, s.AzimuthMaxAngle = 0.5, 0.3 },
+			width: 600 + 12 + 12, height: 1000},
+		{name: "the same start and end angles", angles: func(s *SphereCap) {
+			s.ThetaStartAngle, s.ThetaEndAngle, s.AzimuthStartAngle, s.AzimuthEndAngle = -0.5, 0.5, -0.3, 0.3
+		}, width: 600 + 12 + 12, height: 1000},
+		{name: "more anticlockwise and above", angles: func(s *SphereCap) {
+			s.ThetaStartAngle, s.ThetaEndAngle, s.AzimuthStartAngle, s.AzimuthEndAngle = -0.2, 0.9, -0.3, 1.2
+		}, width: 1500 + 24 + 24, height: 1100},
+		{name: "only below and clockwise", angles: func(s *SphereCap) {
+			s.ThetaStartAngle, s.AzimuthStartAngle = -0.5, -0.3
+		}, width: 300 + 12, height: 500},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := base
+			tc.angles(&s)
+
+			m, err := s.Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if m.Flat.X1-m.Flat.X0 != tc.width || m.Flat.Y1-m.Flat.Y0 != tc.height {
+				t.Errorf("got a %vx%v canvas, want %vx%v", m.Flat.X1-m.Flat.X0, m.Flat.Y1-m.Flat.Y0, tc.width, tc.height)
+			}
+
+			if v := validateModel(m, 1); v.problems() > 0 {
+				t.Errorf("the cap does not fit its canvas: %v %v %v", v.Mismatches, v.Overlaps, v.Outside)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/mrmxf/opentsg-modules/opentsg-core/gridgen"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	AddShapeToHandler[Stage]("A stage volume of a back wall, with a floor and an optional ceiling")
+}
+
+// Stage properties
+type Stage struct {
+	// Wall is the back wall, it is the configuration of a
+	// flatwall, curve or facetedcurve shape.
+	Wall map[string]any `json:"wall" yaml:"wall"`
+	// the floor and the optional ceiling in front of the wall
+	Floor   *StageSurface `json:"floor" yaml:"floor"`
+	Ceiling *StageSurface `json:"ceiling" yaml:"ceiling"`
+	// the bezel and seam gap of the surfaces without their own
+	Seams SeamConfig `json:",inline" yaml:",inline"`
+	// shape name of "stage"
+	ShapeName
+}
+
+// StageSurface is a rectangular floor or ceiling of a stage
+type StageSurface struct {
+	// dimensions of the tiles
+	TileHeight float64 `json:"tileHeight" yaml:"tileHeight"`
+	TileWidth  float64 `json:"tileWidth" yaml:"tileWidth"`
+	// Width is across the wall and depth is out from the wall
+	Width float64 `json:"width" yaml:"width"`
+	Depth float64 `json:"depth" yaml:"depth"`
+	// Offset moves the surface away from the wall
+	Offset float64 `json:"offset" yaml:"offset"`
+	// Height is the height of a ceiling above the floor
+	Height float64 `json:"height" yaml:"height"`
+	// pixels per direction
+	Dx float64 `json:"dx" yaml:"dx"`
+	Dy float64 `json:"dy" yaml:"dy"`
+	// the bezel and seam gap of the tiles of the surface
+	Seams SeamConfig `json:",inline" yaml:",inline"`
+}
+
+// the shapes that can be the wall of a stage
+var stageWalls = []string{FlatWall{}.ObjType(), Curve{}.ObjType(), FacetedCurve{}.ObjType()}
+
+func (s Stage) ObjType() string {
+	return "stage"
+}
+
+// Generate generates a TSIG and OBJ for a stage.
+func (s Stage) Generate(wObj, wTsig io.Writer) error {
+	return generate(s, wObj, wTsig)
+}
+
+// withSeams sets the seams of the stage, unless it has its own.
+func (s Stage) withSeams(seams SeamConfig) Generator {
+	s.Seams = s.Seams.within(seams)
+	return s
+}
+
+/*
+Build builds the model of a stage, of a back wall with a floor and
+an optional ceiling. Each surface has its own tiles.
+
+The stage is viewed from the inside, looking along the x axis at the wall.
+Curved walls are centred around 0,0,0 as the curve shapes are,
+and flat walls are in the y z plane at x = 0. The floor and ceiling are
+centred on the y axis, with their back edge at the front of the wall,
+the closest the ends of the wall come to the viewer.
+
+The flat layout has the ceiling on top, then the wall then the floor, each
+centred across the layout. The floor and ceiling are laid out with the wall
+at the top.
+
+Each surface has the bezel and seam gap of its own block, or of the stage if
+its block has neither.
+*/
+func (s Stage) Build() (*Model, error) {
+
+	wall, wallSeams, err := s.buildWall()
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Floor == nil {
+		return nil, fmt.Errorf("a stage needs a floor")
+	}
+
+	// the front of the wall, where the floor and ceiling meet it
+	front := math.Inf(1)
+	for _, t := range wall.Tiles {
+		for _, c := range t.Corners {
+			front = math.Min(front, c[0])
+		}
+	}
+
+	// the wall is inset once the floor and ceiling can meet its tile pitch
+	if err := applySeams(wall, wallSeams.within(s.Seams)); err != nil {
+		return nil, fmt.Errorf("error building the stage wall: %v", err)
+	}
+
+	floor, err := s.Floor.build(front, false, s.Seams)
+	if err != nil {
+		return nil, fmt.Errorf("error building the stage floor: %v", err)
+	}
+
+	surfaces := []*Model{wall, floor}
+	faces := []string{"wall", "floor"}
+	if s.Ceiling != nil {
+		if s.Ceiling.Height <= 0 {
+			return nil, fmt.Errorf("the stage ceiling must have a height greater than 0, got %v", s.Ceiling.Height)
+		}
+
+		ceiling, err := s.Ceiling.build(front, true, s.Seams)
+		if err != nil {
+			return nil, fmt.Errorf("error building the stage ceiling: %v", err)
+		}
+
+		surfaces = []*Model{ceiling, wall, floor}
+		faces = []string{"ceiling", "wall", "floor"}
+	}
+
+	// stack the surfaces in the flat layout
+	width := 0
+	for _, sm := range surfaces {
+		width = max(width, sm.Flat.X1-sm.Flat.X0)
+	}
+
+	parts := make([]modelPart, len(surfaces))
+	y := 0
+	for i, sm := range surfaces {
+		parts[i] = modelPart{model: sm, face: faces[i], at: gridgen.XY{X: (width - (sm.Flat.X1 - sm.Flat.X0)) / 2, Y: y}}
+		y += sm.Flat.Y1 - sm.Flat.Y0
+	}
+
+	return mergeModels(s.ObjType(), parts), nil
+}
+
+// buildWall builds the wall of the stage, facing the viewer,
+// and returns the seams of its block.
+func (s Stage) buildWall() (*Model, SeamConfig, error) {
+
+	var seams SeamConfig
+	shape, _ := s.Wall["shape"].(string)
+	if shape == "" {
+		return nil, seams, fmt.Errorf("the stage wall needs a shape, one of %v", stageWalls)
+	}
+
+	conf, err := yaml.Marshal(s.Wall)
+	if err != nil {
+		return nil, seams, err
+	}
+
+	if err := yaml.Unmarshal(conf, &seams); err != nil {
+		return nil, seams, err
+	}
+
+	var g Generator
+	switch shape {
+	case FlatWall{}.ObjType():
+		g, err = unmarshalGenerator[FlatWall](conf)
+	case Curve{}.ObjType():
+		g, err = unmarshalGenerator[Curve](conf)
+	case FacetedCurve{}.ObjType():
+		g, err = unmarshalGenerator[FacetedCurve](conf)
+	default:
+		return nil, seams, fmt.Errorf("a stage wall can not be a %s, the wall must be one of %v", shape, stageWalls)
+	}
+	if err != nil {
+		return nil, seams, err
+	}
+
+	// the curves face the inside of the stage
+	switch w := g.(type) {
+	case Curve:
+		if w.Orientation == OrientationConvex {
+			return nil, seams, fmt.Errorf("a stage wall is viewed from the inside, so it can not be convex")
+		}
+		w.Orientation = OrientationConcave
+		g = w
+	case FacetedCurve:
+		if w.Orientation == OrientationConvex {
+			return nil, seams, fmt.Errorf("a stage wall is viewed from the inside, so it can not be convex")
+		}
+		w.Orientation = OrientationConcave
+		g = w
+	}
+
+	wall, err := buildModel(g)
+	if err != nil {
+		return nil, seams, fmt.Errorf("error building the stage wall: %v", err)
+	}
+
+	// turn the flat wall to face back along the x axis, centred on the y axis
+	if f, ok := g.(FlatWall); ok {
+		for i := range wall.Tiles {
+			for c, p := range wall.Tiles[i].Corners {
+				wall.Tiles[i].Corners[c] = [3]float64{p[1], f.WallWidth/2 - p[0], p[2]}
+			}
+		}
+	}
+
+	return wall, seams, nil
+}
+
+// build builds a floor, or a ceiling, in front of the wall,
+// with its own seams or those of the stage.
+func (s StageSurface) build(front float64, ceiling bool, stage SeamConfig) (*Model, error) {
+
+	surface, err := FlatWall{TileHeight: s.TileHeight, TileWidth: s.TileWidth, WallWidth: s.Width, WallHeight: s.Depth, Dx: s.Dx, Dy: s.Dy}.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applySeams(surface, s.Seams.within(stage)); err != nil {
+		return nil, err
+	}
+
+	// lay the flat wall down, with its top at the wall and its left on the
+	// left of the viewer. The ceiling is mirrored to face down, so the
+	// winding of its faces is flipped.
+	back := front - s.Offset - s.Depth
+	height := 0.0
+	if ceiling {
+		height = s.Height
+	}
+
+	for i := range surface.Tiles {
+		t := &surface.Tiles[i]
+		for c, p := range t.Corners {
+			t.Corners[c] = [3]float64{p[2] + back, s.Width/2 - p[0], height}
+		}
+		t.Flip = ceiling != t.Flip
+	}
+
+	return surface, nil
+}
@@ -0,0 +1,112 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/mrmxf/opentsg-modules/opentsg-core/gridgen"
+)
+
+func TestStage(t *testing.T) {
+
+	flatWall := map[string]any{"shape": "flatwall", "tileWidth": 0.5, "tileHeight": 0.5, "wallWidth": 2, "wallHeight": 1, "dx": 10, "dy": 10}
+	curveWall := map[string]any{"shape": "curve", "tileWidth": 0.5, "tileHeight": 0.5, "cylinderRadius": 3, "cylinderHeight": 1, "azimuthMaxAngle": 0.5, "dx": 10, "dy": 10}
+	surface := func(offset, height float64) *StageSurface {
+		return &StageSurface{TileWidth: 0.5, TileHeight: 0.5, Width: 2, Depth: 1, Offset: offset, Height: height, Dx: 10, Dy: 10}
+	}
+
+	for _, tc := range []struct {
+		name  string
+		stage Stage
+		// the tiles of each face, and the canvas
+		faces map[string]int
+		flat  gridgen.XY2D
+		err   string
+	}{
+		{name: "flat wall and floor", stage: Stage{Wall: flatWall, Floor: surface(0, 0)},
+			faces: map[string]int{"wall": 8, "floor": 8}, flat: gridgen.XY2D{X1: 40, Y1: 40}},
+		{name: "flat wall, floor and ceiling", stage: Stage{Wall: flatWall, Floor: surface(0.5, 0), Ceiling: surface(0, 3)},
+			faces: map[string]int{"ceiling": 8, "wall": 8, "floor": 8}, flat: gridgen.XY2D{X1: 40, Y1: 60}},
+		{name: "curved wall", stage: Stage{Wall: curveWall, Floor: surface(0, 0), Ceiling: surface(0, 2)},
+			faces: map[string]int{"ceiling": 8, "wall": 12, "floor": 8}, flat: gridgen.XY2D{X1: 60, Y1: 60}},
+		{name: "no floor", stage: Stage{Wall: flatWall},
+			err: "a stage needs a floor"},
+		{name: "no wall", stage: Stage{Floor: surface(0, 0)},
+			err: "the stage wall needs a shape"},
+		{name: "cube wall", stage: Stage{Wall: map[string]any{"shape": "cube"}, Floor: surface(0, 0)},
+			err: "a stage wall can not be a cube"},
+		{name: "convex wall", stage: Stage{Wall: map[string]any{"shape": "curve", "orientation": "convex"}, Floor: surface(0, 0)},
+			err: "can not be convex"},
+		{name: "ceiling on the floor", stage: Stage{Wall: flatWall, Floor: surface(0, 0), Ceiling: surface(0, 0)},
+			err: "must have a height greater than 0"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := tc.stage.Build()
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("got the error %v, want an error of %q", err, tc.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			faces := map[string]int{}
+			for _, tile := range m.Tiles {
+				faces[tile.Face]++
+			}
+
+			if len(faces) != len(tc.faces) || m.Flat != tc.flat {
+				t.Fatalf("got the faces %v on a %v canvas, want %v on %v", faces, m.Flat, tc.faces, tc.flat)
+			}
+			for face, count := range tc.faces {
+				if faces[face] != count {
+					t.Errorf("got %v %s tiles, want %v", faces[face], face, count)
+				}
+			}
+
+			// the floor and ceiling are level, and run back from the front of the wall,
+			// where the wall comes closest to the viewer
+			front := math.Inf(1)
+			for _, tile := range m.Tiles {
+				if tile.Face == "wall" {
+					for _, c := range tile.Corners {
+						front = math.Min(front, c[0])
+					}
+				}
+			}
+
+			for _, tile := range m.Tiles {
+				var s *StageSurface
+				switch tile.Face {
+				case "floor":
+					s = tc.stage.Floor
+				case "ceiling":
+					s = tc.stage.Ceiling
+				default:
+					continue
+				}
+
+				for _, c := range tile.Corners {
+					if math.Abs(c[2]-s.Height) > 1e-9 || c[0] > front-s.Offset+1e-9 || c[0] < front-s.Offset-s.Depth-1e-9 {
+						t.Errorf("%s has a corner at %v, want it at a height of %v and %v to %v from the wall",
+							tile.Name, c, s.Height, front-s.Offset-s.Depth, front-s.Offset)
+						break
+					}
+				}
+			}
+
+			if v := validateModel(m, 1); v.problems() > 0 {
+				t.Errorf("the stage does not fit its canvas: %v %v %v", v.Mismatches, v.Overlaps, v.Outside)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// gridFromFlat sets the row and column of each tile from its position
+// in the flat layout, relative to the other tiles on its face.
+// The rows are counted from the bottom of the face and the columns from the left.
+func gridFromFlat(tiles []ModelTile) {
+
+	type bounds struct {
+		left, bottom int
+	}
+
+	faces := map[string]*bounds{}
+	for _, t := range tiles {
+		b, ok := faces[t.Face]
+		if !ok {
+			b = &bounds{left: t.Flat.X, bottom: t.Flat.Y + t.Size.Y}
+			faces[t.Face] = b
+		}
+
+		b.left = min(b.left, t.Flat.X)
+		b.bottom = max(b.bottom, t.Flat.Y+t.Size.Y)
+	}
+
+	for i, t := range tiles {
+		b := faces[t.Face]
+		tiles[i].Col = int(math.Round(float64(t.Flat.X-b.left) / float64(t.Size.X)))
+		tiles[i].Row = int(math.Round(float64(b.bottom-(t.Flat.Y+t.Size.Y)) / float64(t.Size.Y)))
+	}
+}
+
+// tileName returns the stable name of a tile,
+// e.g. cube/back/r3c5 or curve/r2c7
+func tileName(shape string, t ModelTile) string {
+	name := shape
+	if t.Face != "" {
+		name += "/" + t.Face
+	}
+
+	return fmt.Sprintf("%s/r%vc%v", name, t.Row, t.Col)
+}
+
+// describeTiles assigns the names, tags and neighbours of every tile.
+// The neighbours are the tiles that share an edge of the obj faces.
+func describeTiles(m *Model) {
+
+	physical := make([]string, len(m.Tiles))
+	for i, t := range m.Tiles {
+		name := tileName(m.Shape, t)
+		physical[i] = name
+
+		tags := []string{}
+		if t.Face != "" {
+			tags = append(tags, "face:"+t.Face)
+		}
+		tags = append(tags, fmt.Sprintf("row:%v", t.Row), fmt.Sprintf("col:%v", t.Col))
+
+		if t.Strip != 0 {
+			// keep the physical tile name, so the strips can be put back together
+			tags = append(tags, fmt.Sprintf("strip:%v", t.Strip), "tile:"+name)
+			name = fmt.Sprintf("%s/s%v", name, t.Strip)
+		}
+
+		m.Tiles[i].Name = name
+		m.Tiles[i].Tags = tags
+	}
+
+	for i, n := range tileNeighbours(physical, m.Tiles) {
+		m.Tiles[i].Neighbours = n
+	}
+}
+
+// tileNeighbours finds the physical tiles that share an edge with each tile.
+// Edges are shared if they are close to parallel and overlap, within a tolerance
+// of the tile size, so tiles that are offset or split into strips are still found.
+func tileNeighbours(physical []string, tiles []ModelTile) [][]string {
+
+	type edge struct {
+		tile int
+		a, b [3]float64
+	}
+
+	// the size of a tile is its longest edge
+	sizes := make([]float64, len(tiles))
+	edges := make([]edge, 0, len(tiles)*4)
+	for i, t := range tiles {
+		for e := 0; e < 4; e++ {
+			a, b := t.Corners[e], t.Corners[(e+1)%4]
+			sizes[i] = max(sizes[i], distance(a, b))
+			edges = append(edges, edge{tile: i, a: a, b: b})
+		}
+	}
+
+	// put the edges into a spatial grid, of the largest tile size
+	// so only the nearby edges are compared.
+	cell := 0.0
+	for _, s := range sizes {
+		cell = max(cell, s)
+	}
+
+	if cell == 0 {
+		return make([][]string, len(tiles))
+	}
+
+	type key [3]int
+	grid := map[key][]int{}
+	keyOf := func(e edge) key {
+		return key{int(math.Floor((e.a[0] + e.b[0]) / (2 * cell))), int(math.Floor((e.a[1] + e.b[1]) / (2 * cell))), int(math.Floor((e.a[2] + e.b[2]) / (2 * cell)))}
+	}
+
+	for i, e := range edges {
+		k := keyOf(e)
+		grid[k] = append(grid[k], i)
+	}
+
+	found := make([]map[string]bool, len(tiles))
+	for i := range found {
+		found[i] = map[string]bool{}
+	}
+
+	for i, e := range edges {
+		k := keyOf(e)
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				for dz := -1; dz <= 1; dz++ {
+					for _, j := range grid[key{k[0] + dx, k[1] + dy, k[2] + dz}] {
+						o := edges[j]
+						if j <= i || physical[o.tile] == physical[e.tile] {
+							continue
+						}
+
+						tol := 0.1 * min(sizes[e.tile], sizes[o.tile])
+						if edgesShared(e.a, e.b, o.a, o.b, tol) {
+							found[e.tile][physical[o.tile]] = true
+							found[o.tile][physical[e.tile]] = true
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// the strips of a tile share the neighbours of the whole tile
+	byPhysical := map[string]map[string]bool{}
+	for i, f := range found {
+		all, ok := byPhysical[physical[i]]
+		if !ok {
+			all = map[string]bool{}
+			byPhysical[physical[i]] = all
+		}
+
+		for n := range f {
+			all[n] = true
+		}
+	}
+
+	neighbours := make([][]string, len(tiles))
+	for i := range neighbours {
+		names := []string{}
+		for n := range byPhysical[physical[i]] {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		neighbours[i] = names
+	}
+
+	return neighbours
+}
+
+// edgesShared checks if the edge c,d lies along the edge a,b within the tolerance,
+// and the two edges overlap by more than half the shorter edge.
+func edgesShared(a, b, c, d [3]float64, tol float64) bool {
+
+	ab := sub(b, a)
+	length := math.Sqrt(dot(ab, ab))
+	cdLength := distance(c, d)
+	if length == 0 || cdLength == 0 {
+		return false
+	}
+
+	// the position along ab and distance away from ab, of a point
+	project := func(p [3]float64) (along, away float64) {
+		ap := sub(p, a)
+		along = dot(ap, ab) / length
+		closest := [3]float64{a[0] + ab[0]*along/length, a[1] + ab[1]*along/length, a[2] + ab[2]*along/length}
+
+		return along, distance(p, closest)
+	}
+
+	tc, dc := project(c)
+	td, dd := project(d)
+	if dc > tol || dd > tol {
+		return false
+	}
+
+	overlap := math.Min(length, math.Max(tc, td)) - math.Max(0, math.Min(tc, td))
+
+	return overlap > 0.5*math.Min(length, cdLength)
+}
+
+func sub(a, b [3]float64) [3]float64 {
+	return [3]float64{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+
+func dot(a, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func distance(a, b [3]float64) float64 {
+	return ThreeDistance(a[0], b[0], a[1], b[1], a[2], b[2])
+}
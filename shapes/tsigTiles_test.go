@@ -0,0 +1,67 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTileNeighbours(t *testing.T) {
+
+	// square returns a tile in the x z plane, with its bottom left at x,z
+	square := func(x, z, width, height float64) ModelTile {
+		return ModelTile{Corners: [4][3]float64{{x, 0, z}, {x + width, 0, z}, {x + width, 0, z + height}, {x, 0, z + height}}}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		physical []string
+		tiles    []ModelTile
+		want     [][]string
+	}{
+		{name: "2x2 wall",
+			physical: []string{"a", "b", "c", "d"},
+			tiles:    []ModelTile{square(0, 0, 1, 1), square(1, 0, 1, 1), square(0, 1, 1, 1), square(1, 1, 1, 1)},
+			want:     [][]string{{"b", "c"}, {"a", "d"}, {"a", "d"}, {"b", "c"}}},
+		{name: "corners only touch",
+			physical: []string{"a", "b"},
+			tiles:    []ModelTile{square(0, 0, 1, 1), square(1, 1, 1, 1)},
+			want:     [][]string{{}, {}}},
+		{name: "offset by a quarter of a tile",
+			physical: []string{"a", "b", "c"},
+			tiles:    []ModelTile{square(0, 0, 1, 1), square(1, 0, 1, 1), square(0.25, 1, 1, 1)},
+			want:     [][]string{{"b", "c"}, {"a"}, {"a"}}},
+		{name: "offset by half a tile or more",
+			physical: []string{"a", "b"},
+			tiles:    []ModelTile{square(0, 0, 1, 1), square(0.5, 1, 1, 1)},
+			want:     [][]string{{}, {}}},
+		{name: "gap within the tolerance",
+			physical: []string{"a", "b"},
+			tiles:    []ModelTile{square(0, 0, 1, 1), square(1.05, 0, 1, 1)},
+			want:     [][]string{{"b"}, {"a"}}},
+		{name: "gap beyond the tolerance",
+			physical: []string{"a", "b"},
+			tiles:    []ModelTile{square(0, 0, 1, 1), square(1.2, 0, 1, 1)},
+			want:     [][]string{{}, {}}},
+		{name: "strips share the neighbours of their tile",
+			physical: []string{"a", "a", "b"},
+			tiles:    []ModelTile{square(0, 0, 1, 0.5), square(0, 0.5, 1, 0.5), square(0, 1, 1, 1)},
+			want:     [][]string{{"b"}, {"b"}, {"a"}}},
+		{name: "faces at a corner",
+			physical: []string{"a", "b"},
+			tiles: []ModelTile{square(0, 0, 1, 1),
+				{Corners: [4][3]float64{{1, 0, 0}, {1, 1, 0}, {1, 1, 1}, {1, 0, 1}}}},
+			want: [][]string{{"b"}, {"a"}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tileNeighbours(tc.physical, tc.tiles)
+			if !slices.EqualFunc(got, tc.want, slices.Equal) {
+				t.Errorf("got the neighbours %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// UpAxisZ keeps the z up coordinates of the shapes.
+	UpAxisZ = "z"
+	// UpAxisY turns the shape to be y up, for engines such as Unity.
+	UpAxisY = "y"
+
+	// HandednessRight keeps the right handed coordinates of the shapes.
+	HandednessRight = "right"
+	// HandednessLeft mirrors the shape into left handed coordinates,
+	// for engines such as Unity and Unreal.
+	HandednessLeft = "left"
+
+	// OriginCentre moves the centre of the shape to the origin.
+	OriginCentre = "centre"
+	// OriginBaseCentre moves the centre of the bottom of the shape to the origin.
+	OriginBaseCentre = "base-centre"
+	// OriginCorner moves the lowest corner in x, y and z of the shape to the origin.
+	OriginCorner = "corner"
+)
+
+// TransformConfig moves the output of any shape into place.
+type TransformConfig struct {
+	// UpAxis is the up axis of the output, z (the default) or y
+	UpAxis string `json:"upAxis" yaml:"upAxis"`
+	// Handedness of the output coordinates, right (the default) or left
+	Handedness string `json:"handedness" yaml:"handedness"`
+	// Origin is the point of the shape that is moved to 0,0,0,
+	// one of centre, base-centre or corner. The origin
+	// of the shape is kept if it is not given.
+	Origin string `json:"origin" yaml:"origin"`
+	// Translate, Rotate and Scale are as the shapes of a scene.
+	Translate [3]float64 `json:"translate" yaml:"translate"`
+	Rotate    [3]float64 `json:"rotate" yaml:"rotate"`
+	Scale     float64    `json:"scale" yaml:"scale"`
+}
+
+// transformFence checks the transform can be written in the mesh format.
+func transformFence(c TransformConfig, meshFormat string) error {
+
+	switch c.UpAxis {
+	case "", UpAxisZ, UpAxisY:
+	default:
+		return fmt.Errorf("unknown up axis %q, the up axis must be %q or %q", c.UpAxis, UpAxisZ, UpAxisY)
+	}
+
+	switch c.Handedness {
+	case "", HandednessRight:
+	case HandednessLeft:
+		if meshFormat != MeshFormatOBJ {
+			return fmt.Errorf("glTF is always right handed, so a left handed transform can only be written as an obj")
+		}
+	default:
+		return fmt.Errorf("unknown handedness %q, the handedness must be %q or %q", c.Handedness, HandednessRight, HandednessLeft)
+	}
+
+	switch c.Origin {
+	case "", OriginCentre, OriginBaseCentre, OriginCorner:
+	default:
+		return fmt.Errorf("unknown origin %q, the origin must be %q, %q or %q", c.Origin, OriginCentre, OriginBaseCentre, OriginCorner)
+	}
+
+	if c.Scale < 0 {
+		return fmt.Errorf("the scale must be greater than 0, got %v, use rotate to turn the shape around", c.Scale)
+	}
+
+	return nil
+}
+
+/*
+applyTransform moves the model by the transform, after it has been built.
+
+The origin is moved first, then the model is scaled, rotated and translated,
+all in the z up coordinates of the shapes. Then the model is turned to its up axis,
+and mirrored if it is left handed. Left handed models have their winding reversed,
+so their faces are front facing in engines that wind clockwise.
+*/
+func applyTransform(m *Model, c TransformConfig) error {
+
+	if err := transformFence(c, MeshFormatOBJ); err != nil {
+		return err
+	}
+
+	if c.Origin != "" {
+		lo := [3]float64{math.Inf(1), math.Inf(1), math.Inf(1)}
+		hi := [3]float64{math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+		for _, t := range m.Tiles {
+			for _, p := range t.Corners {
+				for k := range p {
+					lo[k] = math.Min(lo[k], p[k])
+					hi[k] = math.Max(hi[k], p[k])
+				}
+			}
+		}
+
+		origin := lo
+		switch c.Origin {
+		case OriginCentre:
+			origin = [3]float64{(lo[0] + hi[0]) / 2, (lo[1] + hi[1]) / 2, (lo[2] + hi[2]) / 2}
+		case OriginBaseCentre:
+			origin = [3]float64{(lo[0] + hi[0]) / 2, (lo[1] + hi[1]) / 2, lo[2]}
+		}
+
+		moveTiles(m.Tiles, identity(), 1, [3]float64{-origin[0], -origin[1], -origin[2]})
+	}
+
+	scale := c.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	moveTiles(m.Tiles, rotation(c.Rotate), scale, c.Translate)
+
+	if c.UpAxis == UpAxisY {
+		// turn z up to y up, as glTF does
+		moveTiles(m.Tiles, [3][3]float64{{1, 0, 0}, {0, 0, 1}, {0, -1, 0}}, 1, [3]float64{})
+		m.UpAxis = UpAxisY
+	}
+
+	if c.Handedness == HandednessLeft {
+		// mirror the axis that is not up or x
+		mirror := [3][3]float64{{1, 0, 0}, {0, -1, 0}, {0, 0, 1}}
+		if m.UpAxis == UpAxisY {
+			mirror = [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, -1}}
+		}
+
+		moveTiles(m.Tiles, mirror, 1, [3]float64{})
+		for i := range m.Tiles {
+			m.Tiles[i].Flip = !m.Tiles[i].Flip
+		}
+		m.Handedness = HandednessLeft
+	}
+
+	return nil
+}
+
+// moveTiles turns the tiles by the matrix, then scales
+// them around the origin and translates them.
+func moveTiles(tiles []ModelTile, turn [3][3]float64, scale float64, translate [3]float64) {
+	for i := range tiles {
+		t := &tiles[i]
+		for c := range t.Corners {
+			p := matVec(turn, t.Corners[c])
+			for k := range p {
+				t.Corners[c][k] = p[k]*scale + translate[k]
+			}
+
+			t.Normals[c] = matVec(turn, t.Normals[c])
+		}
+	}
+}
+
+// identity returns the identity matrix
+func identity() [3][3]float64 {
+	return [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+}
+
+// rotation returns the matrix of a rotation in degrees
+// around the x, then the y, then the z axis.
+func rotation(degrees [3]float64) [3][3]float64 {
+
+	r := identity()
+	for axis, deg := range degrees {
+		sin, cos := math.Sincos(deg * math.Pi / 180)
+		a, b := (axis+1)%3, (axis+2)%3
+
+		turn := [3][3]float64{}
+		turn[axis][axis] = 1
+		turn[a][a], turn[a][b] = cos, -sin
+		turn[b][a], turn[b][b] = sin, cos
+		r = matMul(turn, r)
+	}
+
+	return r
+}
+
+// matMul returns the matrix product a b
+func matMul(a, b [3][3]float64) [3][3]float64 {
+	var m [3][3]float64
+	for i := range m {
+		for j := range m[i] {
+			for k := 0; k < 3; k++ {
+				m[i][j] += a[i][k] * b[k][j]
+			}
+		}
+	}
+
+	return m
+}
+
+// matVec returns the matrix product m v
+func matVec(m [3][3]float64, v [3]float64) [3]float64 {
+	var p [3]float64
+	for i := range p {
+		for k := 0; k < 3; k++ {
+			p[i] += m[i][k] * v[k]
+		}
+	}
+
+	return p
+}
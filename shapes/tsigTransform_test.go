@@ -0,0 +1,72 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestApplyTransform(t *testing.T) {
+
+	for _, tc := range []struct {
+		name   string
+		config TransformConfig
+		// the bottom left and top right corners of r0c0, and its front
+		bottomLeft, topRight, front [3]float64
+		flip                        bool
+	}{
+		{name: "right handed z up", config: TransformConfig{Translate: [3]float64{0, 1, 0}},
+			bottomLeft: [3]float64{0, 1, 0}, topRight: [3]float64{1, 1, 1}, front: [3]float64{0, -1, 0}},
+		{name: "left handed z up", config: TransformConfig{Translate: [3]float64{0, 1, 0}, Handedness: HandednessLeft},
+			bottomLeft: [3]float64{0, -1, 0}, topRight: [3]float64{1, -1, 1}, front: [3]float64{0, 1, 0}, flip: true},
+		{name: "right handed y up", config: TransformConfig{Translate: [3]float64{0, 1, 0}, UpAxis: UpAxisY},
+			bottomLeft: [3]float64{0, 0, -1}, topRight: [3]float64{1, 1, -1}, front: [3]float64{0, 0, 1}},
+		{name: "left handed y up", config: TransformConfig{Translate: [3]float64{0, 1, 0}, UpAxis: UpAxisY, Handedness: HandednessLeft},
+			bottomLeft: [3]float64{0, 0, 1}, topRight: [3]float64{1, 1, 1}, front: [3]float64{0, 0, -1}, flip: true},
+		{name: "scaled and turned", config: TransformConfig{Scale: 2, Rotate: [3]float64{0, 0, 90}},
+			bottomLeft: [3]float64{0, 0, 0}, topRight: [3]float64{0, 2, 2}, front: [3]float64{1, 0, 0}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := testWall(t, 3, 2)
+			for i := range m.Tiles {
+				m.Tiles[i].Normals = [4][3]float64{{0, -1, 0}, {0, -1, 0}, {0, -1, 0}, {0, -1, 0}}
+			}
+
+			if err := applyTransform(m, tc.config); err != nil {
+				t.Fatal(err)
+			}
+
+			tile := m.Tiles[0]
+			if !near(tile.Corners[0], tc.bottomLeft) || !near(tile.Corners[2], tc.topRight) {
+				t.Errorf("%s is from %v to %v, want %v to %v", tile.Name, tile.Corners[0], tile.Corners[2], tc.bottomLeft, tc.topRight)
+			}
+
+			if tile.Flip != tc.flip {
+				t.Errorf("%s has a flip of %v, want %v", tile.Name, tile.Flip, tc.flip)
+			}
+
+			// the winding and the normals still point out of the front
+			if n := faceNormal(tile); !near(n, tc.front) {
+				t.Errorf("%s faces %v, want %v", tile.Name, n, tc.front)
+			}
+			for c, n := range tile.Normals {
+				if !near(n, tc.front) {
+					t.Errorf("%s normal %v is %v, want %v", tile.Name, c, n, tc.front)
+				}
+			}
+
+			// the handedness is written to the obj, for the previews
+			var obj bytes.Buffer
+			if err := writeOBJ(&obj, m); err != nil {
+				t.Fatal(err)
+			}
+			if want := tc.config.Handedness == HandednessLeft; (previewHandedness(obj.Bytes()) == HandednessLeft) != want {
+				t.Errorf("the obj is read as %s handed", previewHandedness(obj.Bytes()))
+			}
+		})
+	}
+}
@@ -0,0 +1,334 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// the length of each unit in millimetres,
+// which is the internal unit of the lengths once units are given
+var unitLengths = map[string]float64{
+	"mm": 1,
+	"cm": 10,
+	"m":  1000,
+	"in": 25.4,
+}
+
+// lengthFields are the fields of the shapes, and of the blocks of a
+// configuration, that are lengths. Lists of lengths, such as a translation,
+// are converted as a whole.
+var lengthFields = []string{"tileWidth", "tileHeight", "wallWidth", "wallHeight",
+	"cubeWidth", "cubeHeight", "cubeDepth", "cylinderRadius", "cylinderHeight", "radius",
+	"width", "depth", "offset", "height", "bezel", "seamGap", "translate"}
+
+// unitlessBlocks are the blocks of a configuration that do not have lengths,
+// such as the carve outputs which are in pixels.
+var unitlessBlocks = map[string]bool{"carve": true, "wiring": true}
+
+// UnitConfig is the physical units of a configuration.
+type UnitConfig struct {
+	// Units are the units of the lengths in the configuration, and of
+	// the outputs, one of mm, cm, m or in. Blocks of the configuration
+	// can give their own units. If they are not given the lengths are abstract.
+	Units string `json:"units" yaml:"units"`
+	// PixelPitch is the distance between pixels in millimetres, as given on
+	// tile spec sheets. It is used in place of dx and dy.
+	PixelPitch float64 `json:"pixelPitch" yaml:"pixelPitch"`
+}
+
+/*
+applyUnits checks the units of a configuration, converts every length to
+millimetres and derives the dx and dy pixels per tile from the pixel pitch,
+if one is given.
+
+The configuration is returned with its lengths in millimetres and the dx and dy
+set, so it can be read by any shape and the model is built in millimetres. The
+units are kept as those of the outputs. Blocks of the configuration, such as the
+shapes of a scene, can give their own units, for the lengths of the block and
+the blocks within it. If no units are given the lengths are left as they are.
+
+The pixels only cover the lit area of the tile, inside any bezel and seam gap.
+If the tile is not a whole number of pixels at the pitch, the pixels are
+rounded and a note of the rounding is returned.
+
+Shapes made of other shapes, such as stages and scenes, give the tile size in
+blocks of the configuration. The pixels are derived for every block with a tile
+size, at the pixel pitch of the block, or of the top level if the block has no
+pitch of its own. Blocks that already have their dx and dy, such as those of a
+catalog tile, keep them unless they have their own pitch. The bezel and seam
+gap are also those of the block, or of the block it is in if it has neither,
+as the seams of the parts of a shape are.
+*/
+func applyUnits(conf []byte) ([]byte, UnitConfig, []string, error) {
+
+	var units UnitConfig
+	if err := yaml.Unmarshal(conf, &units); err != nil {
+		return nil, units, nil, err
+	}
+
+	if units.Units != "" {
+		if _, ok := unitLengths[units.Units]; !ok {
+			return nil, units, nil, fmt.Errorf("unknown units %q, the units must be mm, cm, m or in", units.Units)
+		}
+	}
+
+	fields := map[string]any{}
+	if err := yaml.Unmarshal(conf, &fields); err != nil {
+		return nil, units, nil, err
+	}
+
+	// the blocks with a tile size or a pitch of their own, and the top level
+	// if it has a tile size or there are no blocks to take the pitch
+	top := unitBlock{fields: fields, units: units.Units}
+	top.toMillimetres()
+	top.seams = top.seamsWithin(SeamConfig{})
+	blocks := []unitBlock{}
+	for _, k := range sortedKeys(fields) {
+		if unitlessBlocks[k] {
+			continue
+		}
+
+		var err error
+		blocks, err = sizedBlocks(fields[k], k, top, blocks)
+		if err != nil {
+			return nil, units, nil, err
+		}
+	}
+
+	_, width := fields["tileWidth"]
+	_, height := fields["tileHeight"]
+	if width || height || len(blocks) == 0 {
+		blocks = append([]unitBlock{top}, blocks...)
+	}
+
+	notes, pixelled := []string{}, false
+	for _, b := range blocks {
+		pitch, own := b.number("pixelPitch")
+		if !own {
+			pitch = units.PixelPitch
+		}
+
+		if pitch == 0 {
+			continue
+		}
+
+		if pitch < 0 {
+			return nil, units, nil, b.errorf("the pixel pitch must be greater than 0, got %v", pitch)
+		}
+
+		if units.Units == "" {
+			return nil, units, nil, b.errorf("a pixel pitch of %vmm needs the units of the tile dimensions to be given", pitch)
+		}
+
+		// a block keeps the pixels it has, unless it has its own pitch
+		_, dx := b.fields["dx"]
+		_, dy := b.fields["dy"]
+		if b.path != "" && !own && dx && dy {
+			continue
+		}
+
+		if dx {
+			return nil, units, nil, b.errorf("dx and a pixel pitch can not both be given")
+		}
+		if dy {
+			return nil, units, nil, b.errorf("dy and a pixel pitch can not both be given")
+		}
+
+		// the lit area is inset by the bezel and half the seam gap on each side
+		bezel, gap := b.seams.Bezel, b.seams.SeamGap
+
+		for _, dim := range []struct{ size, pixels string }{{"tileWidth", "dx"}, {"tileHeight", "dy"}} {
+			size, ok := b.number(dim.size)
+			if !ok {
+				return nil, units, nil, b.errorf("a pixel pitch needs a %s to be given", dim.size)
+			}
+
+			// the size as it was given, for the messages
+			given := size / unitLengths[b.units]
+
+			exact := (size - 2*bezel - gap) / pitch
+			pixels := math.Round(exact)
+			if pixels < 1 {
+				return nil, units, nil, b.errorf("a %s of %v%s is less than a pixel at a pixel pitch of %vmm", dim.size, given, b.units, pitch)
+			}
+
+			if math.Abs(exact-pixels) > 1e-6 {
+				notes = append(notes, b.prefix()+fmt.Sprintf("a %s of %v%s is %.2f pixels at a pixel pitch of %vmm, %v pixels are used", dim.size, given, b.units, exact, pitch, pixels))
+			}
+
+			b.fields[dim.pixels] = pixels
+		}
+		pixelled = true
+	}
+
+	// the lengths are only converted if there are units
+	if !pixelled && units.Units == "" {
+		return conf, units, nil, nil
+	}
+
+	conf, err := yaml.Marshal(fields)
+	if err != nil {
+		return nil, units, nil, err
+	}
+
+	return conf, units, notes, nil
+}
+
+// unitLength is the length of the units of a model in the millimetres
+// of its geometry, which is 1 if the units are abstract.
+func unitLength(units string) float64 {
+	if length, ok := unitLengths[units]; ok {
+		return length
+	}
+	return 1
+}
+
+// unitBlock is a block of a configuration, and the path to it
+// from the top level, which is empty for the top level.
+type unitBlock struct {
+	path   string
+	fields map[string]any
+	// the units the lengths of the block were given in,
+	// and the seams of the tiles of the block
+	units string
+	seams SeamConfig
+}
+
+// toMillimetres converts the lengths of the block from its units to millimetres,
+// the lengths are left as they are if the block has no units.
+func (b unitBlock) toMillimetres() {
+	length, ok := unitLengths[b.units]
+	if !ok {
+		return
+	}
+
+	for _, field := range lengthFields {
+		if v, ok := b.number(field); ok {
+			b.fields[field] = v * length
+		}
+
+		// lists of lengths
+		if list, ok := b.fields[field].([]any); ok {
+			for i := range list {
+				if v, ok := number(list[i]); ok {
+					list[i] = v * length
+				}
+			}
+		}
+	}
+}
+
+// seamsWithin returns the seams of the block, within the block it is in.
+func (b unitBlock) seamsWithin(outer SeamConfig) SeamConfig {
+	bezel, _ := b.number("bezel")
+	gap, _ := b.number("seamGap")
+	return SeamConfig{Bezel: bezel, SeamGap: gap}.within(outer)
+}
+
+// number returns the number of a field of the block, and if it is given.
+func (b unitBlock) number(field string) (float64, bool) {
+	return number(b.fields[field])
+}
+
+// number returns a value read from a configuration as a number, and if it is one.
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+// prefix returns the path of the block to start a message with
+func (b unitBlock) prefix() string {
+	if b.path == "" {
+		return ""
+	}
+	return b.path + ": "
+}
+
+// errorf returns an error of the block
+func (b unitBlock) errorf(format string, a ...any) error {
+	return fmt.Errorf(b.prefix()+format, a...)
+}
+
+// sortedKeys returns the keys of the block in order, so
+// the blocks within it are always found in the same order.
+func sortedKeys(b map[string]any) []string {
+	keys := make([]string, 0, len(b))
+	for k := range b {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+/*
+sizedBlocks converts the lengths of the value, and the blocks within it, to
+millimetres and appends the blocks that give a tile size or a pixel pitch.
+
+A block has its own units, or those of the block it is in, and its own seams
+within those of the block it is in. The units of a block are removed once its
+lengths are converted, as they are no longer the units of the block.
+*/
+func sizedBlocks(v any, path string, outer unitBlock, blocks []unitBlock) ([]unitBlock, error) {
+	switch b := v.(type) {
+	case map[string]any:
+		block := unitBlock{path: path, fields: b, units: outer.units}
+		if u, ok := b["units"]; ok {
+			units := fmt.Sprint(u)
+			if _, ok := unitLengths[units]; !ok {
+				return nil, block.errorf("unknown units %q, the units must be mm, cm, m or in", units)
+			}
+			if outer.units == "" {
+				return nil, block.errorf("units of %s need the units of the whole configuration to be given", units)
+			}
+
+			block.units = units
+			delete(b, "units")
+		}
+
+		block.toMillimetres()
+		block.seams = block.seamsWithin(outer.seams)
+		for _, field := range []string{"tileWidth", "tileHeight", "pixelPitch"} {
+			if _, ok := b[field]; ok {
+				blocks = append(blocks, block)
+				break
+			}
+		}
+
+		for _, k := range sortedKeys(b) {
+			if unitlessBlocks[k] {
+				continue
+			}
+
+			var err error
+			blocks, err = sizedBlocks(b[k], path+"."+k, block, blocks)
+			if err != nil {
+				return nil, err
+			}
+		}
+	case []any:
+		for i, f := range b {
+			var err error
+			blocks, err = sizedBlocks(f, fmt.Sprintf("%s[%v]", path, i), outer, blocks)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return blocks, nil
+}
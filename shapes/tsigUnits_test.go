@@ -0,0 +1,143 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configValue returns the value of a configuration at the path, of the
+// keys of the maps and the indices of the lists separated by dots.
+func configValue(fields map[string]any, path string) any {
+	var v any = fields
+	if path == "" {
+		return v
+	}
+
+	for _, k := range strings.Split(path, ".") {
+		switch b := v.(type) {
+		case map[string]any:
+			v = b[k]
+		case []any:
+			i, _ := strconv.Atoi(k)
+			if i >= len(b) {
+				return nil
+			}
+			v = b[i]
+		default:
+			return nil
+		}
+	}
+
+	return v
+}
+
+func TestApplyUnits(t *testing.T) {
+
+	for _, tc := range []struct {
+		name string
+		conf string
+		// pixels are the dx and dy of each block, by its path,
+		// and lengths are the lengths in millimetres by their path
+		pixels  map[string][2]float64
+		lengths map[string]float64
+		notes   int
+		err     string
+	}{
+		{name: "top level pitch",
+			conf:   "shape: flatwall\nunits: mm\npixelPitch: 2.5\ntileWidth: 500\ntileHeight: 250\n",
+			pixels: map[string][2]float64{"": {200, 100}}},
+		{name: "rounded pixels",
+			conf:   "shape: flatwall\nunits: mm\npixelPitch: 2.6\ntileWidth: 500\ntileHeight: 500\n",
+			pixels: map[string][2]float64{"": {192, 192}}, notes: 2},
+		{name: "metres",
+			conf:   "shape: flatwall\nunits: m\npixelPitch: 2.5\ntileWidth: 0.5\ntileHeight: 0.5\n",
+			pixels: map[string][2]float64{"": {200, 200}}},
+		{name: "no pitch", conf: "shape: flatwall\ntileWidth: 500\ntileHeight: 500\ndx: 10\ndy: 20\n",
+			pixels: map[string][2]float64{"": {10, 20}}, lengths: map[string]float64{"tileWidth": 500}},
+		{name: "lengths in millimetres",
+			conf:    "shape: flatwall\nunits: m\ntileWidth: 0.5\ntileHeight: 0.25\nwallWidth: 3\nbezel: 0.005\ndx: 10\ndy: 20\ntransform:\n  translate: [1, 0, 0.5]\n",
+			pixels:  map[string][2]float64{"": {10, 20}},
+			lengths: map[string]float64{"tileWidth": 500, "tileHeight": 250, "wallWidth": 3000, "bezel": 5, "transform.translate.0": 1000, "transform.translate.2": 500}},
+		{name: "carve outputs are in pixels",
+			conf:    "shape: flatwall\nunits: m\ntileWidth: 0.5\ntileHeight: 0.5\ndx: 10\ndy: 10\ncarve:\n  outputs:\n    - width: 1920\n      height: 1080\n",
+			lengths: map[string]float64{"tileWidth": 500, "carve.outputs.0.width": 1920}},
+		{name: "blocks with their own units",
+			conf: "shape: scene\nunits: m\npixelPitch: 2.5\nshapes:\n" +
+				"  - translate: [1, 0, 0]\n    config:\n      shape: flatwall\n      units: mm\n      tileWidth: 500\n      tileHeight: 500\n      wallWidth: 1000\n" +
+				"  - translate: [2, 0, 0]\n    units: cm\n    config:\n      shape: flatwall\n      tileWidth: 50\n      tileHeight: 50\n",
+			pixels: map[string][2]float64{"shapes.0.config": {200, 200}, "shapes.1.config": {200, 200}},
+			lengths: map[string]float64{"shapes.0.translate.0": 1000, "shapes.0.config.tileWidth": 500, "shapes.0.config.wallWidth": 1000,
+				"shapes.1.translate.0": 20, "shapes.1.config.tileWidth": 500}},
+		{name: "lit area inside the bezel",
+			conf:   "shape: flatwall\nunits: mm\npixelPitch: 2.5\ntileWidth: 500\ntileHeight: 500\nbezel: 5\nseamGap: 10\n",
+			pixels: map[string][2]float64{"": {192, 192}}},
+		{name: "nested blocks",
+			conf: "shape: stage\nunits: m\npixelPitch: 2.5\nbezel: 0.005\n" +
+				"wall:\n  shape: flatwall\n  tileWidth: 0.5\n  tileHeight: 0.5\n" +
+				"floor:\n  tileWidth: 0.5\n  tileHeight: 0.5\n  pixelPitch: 5\n  bezel: 0\n  seamGap: 0.01\n" +
+				"ceiling:\n  tileWidth: 1\n  tileHeight: 1\n  dx: 256\n  dy: 256\n",
+			pixels: map[string][2]float64{"wall": {196, 196}, "floor": {98, 98}, "ceiling": {256, 256}}},
+		{name: "seams of the block it is in",
+			conf: "shape: scene\nunits: m\npixelPitch: 2.5\nbezel: 0.01\nshapes:\n" +
+				"  - config:\n      shape: stage\n      bezel: 0.005\n" +
+				"      wall:\n        shape: flatwall\n        tileWidth: 0.5\n        tileHeight: 0.5\n" +
+				"  - config:\n      shape: flatwall\n      tileWidth: 0.5\n      tileHeight: 0.5\n",
+			pixels: map[string][2]float64{"shapes.0.config.wall": {196, 196}, "shapes.1.config": {192, 192}}},
+		{name: "no tile size", conf: "shape: flatwall\nunits: mm\npixelPitch: 2.5\nwallWidth: 500\n", err: "needs a tileWidth"},
+		{name: "nested block without a tile height",
+			conf: "shape: stage\nunits: mm\npixelPitch: 2.5\nwall:\n  tileWidth: 500\n", err: "wall: a pixel pitch needs a tileHeight"},
+		{name: "dx and a pitch", conf: "shape: flatwall\nunits: mm\npixelPitch: 2.5\ntileWidth: 500\ntileHeight: 500\ndx: 200\n", err: "dx and a pixel pitch"},
+		{name: "no units", conf: "shape: flatwall\npixelPitch: 2.5\ntileWidth: 500\ntileHeight: 500\n", err: "needs the units"},
+		{name: "unknown units", conf: "shape: flatwall\nunits: ft\ntileWidth: 500\ntileHeight: 500\n", err: "unknown units"},
+		{name: "unknown block units", conf: "shape: stage\nunits: m\nwall:\n  units: ft\n", err: "wall: unknown units"},
+		{name: "block units without units", conf: "shape: stage\nwall:\n  units: mm\n", err: "wall: units of mm need the units"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			conf, _, notes, err := applyUnits([]byte(tc.conf))
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("got the error %v, want an error of %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fields := map[string]any{}
+			if err := yaml.Unmarshal(conf, &fields); err != nil {
+				t.Fatal(err)
+			}
+
+			for path, want := range tc.pixels {
+				block, _ := configValue(fields, path).(map[string]any)
+
+				b := unitBlock{fields: block}
+				dx, _ := b.number("dx")
+				dy, _ := b.number("dy")
+				if dx != want[0] || dy != want[1] {
+					t.Errorf("block %q has %vx%v pixels, want %vx%v", path, dx, dy, want[0], want[1])
+				}
+			}
+
+			for path, want := range tc.lengths {
+				if v, _ := number(configValue(fields, path)); math.Abs(v-want) > 1e-9 {
+					t.Errorf("%s is %v, want %v", path, v, want)
+				}
+			}
+
+			if len(notes) != tc.notes {
+				t.Errorf("got the notes %v, want %v notes", notes, tc.notes)
+			}
+		})
+	}
+}
@@ -4,7 +4,10 @@
 // Package shapes contains the obj shapes and their configurations
 package shapes
 
-import "math"
+import (
+	"fmt"
+	"math"
+)
 
 // PolarToCartesian takes polar coordinates of R, theta (inclination angle) and
 // phi (Azimuth angle) and converts them to cartesian XYZ
@@ -40,3 +43,23 @@ func CylindricalToCartesian(r, z, azimuth float64) (X, Y, Z float64) {
 func ThreeDistance(x1, x2, y1, y2, z1, z2 float64) float64 {
 	return math.Sqrt(math.Pow((x1)-x2, 2) + math.Pow(y1-y2, 2) + math.Pow(z1-z2, 2))
 }
+
+const (
+	// OrientationConcave faces the display towards the origin,
+	// for displays that are viewed from the inside.
+	OrientationConcave = "concave"
+	// OrientationConvex faces the display away from the origin,
+	// for displays that are viewed from the outside.
+	OrientationConvex = "convex"
+)
+
+// orientationFence checks the orientation is a valid value.
+// An empty orientation keeps the original layout of the shape.
+func orientationFence(orientation string) error {
+	switch orientation {
+	case "", OrientationConcave, OrientationConvex:
+		return nil
+	default:
+		return fmt.Errorf("unknown orientation %q, the orientation must be %q or %q", orientation, OrientationConcave, OrientationConvex)
+	}
+}
@@ -0,0 +1,465 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mrmxf/opentsg-modules/opentsg-core/gridgen"
+	"github.com/spf13/cobra"
+)
+
+// add the command and its flags to the main handler
+func init() {
+	cmdValidate.Flags().StringVar(&objFile, "obj", "", "The obj file to validate")
+	cmdValidate.Flags().StringVar(&tsigFile, "tsig", "", "The TSIG file of the obj")
+	cmdValidate.Flags().Float64Var(&pixelTolerance, "tolerance", 1, "The pixels a uv map and flat layout can be apart, and tiles can overlap")
+
+	cmdBoth.AddCommand(cmdValidate)
+}
+
+// validate flags, the obj and TSIG files are
+// also read by the other commands that check outputs
+var (
+	objFile        = ""
+	tsigFile       = ""
+	pixelTolerance = 1.0
+)
+
+var cmdValidate = &cobra.Command{
+	Use:   "validate",
+	Short: "check an obj against its TSIG",
+	Long: `
+	Check the uv map of an obj lands on the flat layout of its TSIG,
+	and that the tiles of the TSIG fit together on the flat canvas.
+	The tiles that overlap or are outside the canvas are reported,
+	as well as the regions of the canvas that no tile covers.
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		fObj, err := os.Open(objFile)
+		if err != nil {
+			return err
+		}
+		defer fObj.Close()
+
+		fTSIG, err := os.Open(tsigFile)
+		if err != nil {
+			return err
+		}
+		defer fTSIG.Close()
+
+		model, err := modelFromOutput(objFile, fObj, fTSIG)
+		if err != nil {
+			return err
+		}
+
+		v := validateModel(model, pixelTolerance)
+		for _, check := range []struct {
+			title  string
+			issues []string
+		}{{"uv map and flat layout mismatches", v.Mismatches}, {"overlapping tiles", v.Overlaps},
+			{"tiles outside the canvas", v.Outside}, {"uncovered regions of the canvas", v.Uncovered}} {
+
+			fmt.Printf("%v %s\n", len(check.issues), check.title)
+			for _, issue := range check.issues {
+				fmt.Printf(" - %s\n", issue)
+			}
+		}
+
+		canvas := (model.Flat.X1 - model.Flat.X0) * (model.Flat.Y1 - model.Flat.Y0)
+		if canvas > 0 {
+			fmt.Printf("%v tiles checked, %.3g%% of the canvas is uncovered\n", len(model.Tiles), 100*float64(v.UncoveredPixels)/float64(canvas))
+		}
+
+		if v.problems() > 0 {
+			return fmt.Errorf("%s and %s do not match, %v problems were found", objFile, tsigFile, v.problems())
+		}
+
+		return nil
+	},
+}
+
+// validation is the report of the checks of an obj against its TSIG.
+type validation struct {
+	// the tiles where the uv map does not land on the flat layout
+	Mismatches []string
+	// the tiles that overlap on the flat layout
+	Overlaps []string
+	// the tiles that are not inside the flat canvas
+	Outside []string
+	// the regions of the canvas that no tile covers, and their pixel area
+	Uncovered       []string
+	UncoveredPixels int
+}
+
+// problems is the count of the problems found, the uncovered
+// regions are not counted as most shapes do not fill their canvas.
+func (v validation) problems() int {
+	return len(v.Mismatches) + len(v.Overlaps) + len(v.Outside)
+}
+
+// tileRect is the region of a tile on the flat canvas,
+// from its top left pixel up to, but not including, its bottom right.
+type tileRect struct {
+	name           string
+	x0, y0, x1, y1 float64
+}
+
+/*
+validateModel checks the uv map of each tile of a model read from an obj and TSIG
+lands on its flat position, and that the tiles fit together on the flat canvas.
+
+Each corner of a tile, in the winding order of the obj, is checked against the
+flat corner it should be on. The flat corner is found from the way the uv map
+runs across the neighbouring tiles, so a tile whose uv map is mirrored or turned
+is found, even though it covers the right pixels.
+
+Positions that are within the tolerance, in pixels, are counted as the same. So
+tiles that overlap by the tolerance or less, or are only outside the canvas by
+the tolerance, are not reported.
+*/
+func validateModel(m *Model, tolerance float64) validation {
+
+	// the positions are rounded to whole pixels, so allow for
+	// the floating point error of the uv map
+	beyond := func(d float64) bool {
+		return d > tolerance+1e-6
+	}
+
+	var v validation
+	width, height := float64(m.Flat.X1-m.Flat.X0), float64(m.Flat.Y1-m.Flat.Y0)
+
+	frames := uvFrames(m.Tiles)
+
+	rects := make([]tileRect, len(m.Tiles))
+	for i, t := range m.Tiles {
+		r := tileRect{name: t.Name, x0: float64(t.Flat.X), y0: float64(t.Flat.Y), x1: float64(t.Flat.X + t.Size.X), y1: float64(t.Flat.Y + t.Size.Y)}
+		rects[i] = r
+
+		// how far each uv corner is from the flat corner it should be on
+		off := func(frame [2][3]float64) (float64, int) {
+			worst, corner := 0.0, 0
+			for c, uv := range t.UVs {
+				x := float64(m.Flat.X0) + uv[0]*width
+				y := float64(m.Flat.Y0) + (1-uv[1])*height
+				fx, fy := r.flatCorner(t.Corners[c], frames[i].centre, frame)
+				if d := math.Max(math.Abs(x-fx), math.Abs(y-fy)); d > worst {
+					worst, corner = d, c
+				}
+			}
+
+			return worst, corner
+		}
+
+		own, corner := off(frames[i].axes)
+		turned, _ := off(frames[i].neighbours)
+		switch {
+		case beyond(own):
+			uv := t.UVs[corner]
+			v.Mismatches = append(v.Mismatches, fmt.Sprintf("%s: corner %v of the uv map is at %.6g,%.6g but the flat layout is %s, %.4g pixels out",
+				t.Name, corner, float64(m.Flat.X0)+uv[0]*width, float64(m.Flat.Y0)+(1-uv[1])*height, r, own))
+		case beyond(turned):
+			v.Mismatches = append(v.Mismatches, fmt.Sprintf("%s: the uv map is mirrored or turned from the uv maps of its neighbours", t.Name))
+		}
+
+		if beyond(float64(m.Flat.X0)-r.x0) || beyond(float64(m.Flat.Y0)-r.y0) ||
+			beyond(r.x1-float64(m.Flat.X1)) || beyond(r.y1-float64(m.Flat.Y1)) {
+			v.Outside = append(v.Outside, fmt.Sprintf("%s: %s is not inside the canvas %s", t.Name, r, canvasRect(m.Flat)))
+		}
+	}
+
+	// sweep across the canvas, so only tiles that are level in x are compared
+	order := make([]int, len(rects))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return rects[order[a]].x0 < rects[order[b]].x0 })
+
+	for i, a := range order {
+		ra := rects[a]
+		for _, b := range order[i+1:] {
+			rb := rects[b]
+			if rb.x0 >= ra.x1-tolerance {
+				break
+			}
+
+			w := math.Min(ra.x1, rb.x1) - math.Max(ra.x0, rb.x0)
+			h := math.Min(ra.y1, rb.y1) - math.Max(ra.y0, rb.y0)
+			if beyond(w) && beyond(h) {
+				v.Overlaps = append(v.Overlaps, fmt.Sprintf("%s and %s overlap by %.6gx%.6g pixels", ra.name, rb.name, w, h))
+			}
+		}
+	}
+
+	v.Uncovered, v.UncoveredPixels = uncoveredRegions(m.Flat, rects, tolerance)
+
+	return v
+}
+
+// uvFrame is the way the uv map runs across a tile, as the directions
+// of the right and the top of the flat layout on the tile.
+type uvFrame struct {
+	centre [3]float64
+	// the directions from the uv map of the tile
+	axes [2][3]float64
+	// the directions of the tile turned or reversed to run the way
+	// most of the neighbouring tiles do, which are the directions
+	// from the uv map if the tile has no neighbours
+	neighbours [2][3]float64
+}
+
+/*
+uvFrames finds the directions of the uv map across each tile and its neighbours.
+
+Only the neighbours that face within 60 degrees of the tile are used, so the uv map
+can turn at the edges of a cube or stage. The neighbours are found from the corners,
+so the neighbours in the TSIG are not needed.
+*/
+func uvFrames(tiles []ModelTile) []uvFrame {
+
+	frames := make([]uvFrame, len(tiles))
+	normals := make([][3]float64, len(tiles))
+	for i, t := range tiles {
+		var f uvFrame
+		var u, v float64
+		for c := range t.Corners {
+			for k := range f.centre {
+				f.centre[k] += t.Corners[c][k] / float64(len(t.Corners))
+			}
+			u += t.UVs[c][0] / float64(len(t.UVs))
+			v += t.UVs[c][1] / float64(len(t.UVs))
+		}
+
+		// the corners weighted by how far right and up they are on the uv map
+		for c, p := range t.Corners {
+			for k := range p {
+				f.axes[0][k] += (t.UVs[c][0] - u) * (p[k] - f.centre[k])
+				f.axes[1][k] += (t.UVs[c][1] - v) * (p[k] - f.centre[k])
+			}
+		}
+		f.neighbours = f.axes
+		frames[i] = f
+
+		if len(t.Corners) == 4 {
+			n := cross(sub(t.Corners[2], t.Corners[0]), sub(t.Corners[3], t.Corners[1]))
+			if l := math.Sqrt(dot(n, n)); l > 0 {
+				normals[i] = [3]float64{n[0] / l, n[1] / l, n[2] / l}
+			}
+		}
+	}
+
+	// the strips of a tile are one physical tile
+	physical := make([]string, len(tiles))
+	byPhysical := map[string][]int{}
+	for i, t := range tiles {
+		physical[i] = t.Name
+		for _, tag := range t.Tags {
+			if name, ok := strings.CutPrefix(tag, "tile:"); ok {
+				physical[i] = name
+			}
+		}
+		if physical[i] == "" {
+			physical[i] = fmt.Sprintf("#%v", i)
+		}
+		byPhysical[physical[i]] = append(byPhysical[physical[i]], i)
+	}
+
+	unit := func(a [3]float64) [3]float64 {
+		l := math.Sqrt(dot(a, a))
+		if l == 0 {
+			return a
+		}
+		return [3]float64{a[0] / l, a[1] / l, a[2] / l}
+	}
+
+	for i, names := range tileNeighbours(physical, tiles) {
+		near := []int{}
+		for _, name := range names {
+			for _, j := range byPhysical[name] {
+				if math.Abs(dot(normals[i], normals[j])) >= 0.5 {
+					near = append(near, j)
+				}
+			}
+		}
+
+		for axis := range frames[i].neighbours {
+			// take the direction of the neighbour that the most other neighbours
+			// agree with, so one wrong neighbour is outvoted. When they are
+			// even the direction closest to the tile is taken.
+			own := unit(frames[i].axes[axis])
+			best := math.Inf(-1)
+			for _, j := range near {
+				a := unit(frames[j].axes[axis])
+				score := 1e-3 * dot(a, own)
+				for _, k := range near {
+					score += dot(a, unit(frames[k].axes[axis]))
+				}
+
+				if score > best {
+					best, frames[i].neighbours[axis] = score, a
+				}
+			}
+		}
+
+		// keep the directions of the tile, in the order and way round of
+		// the neighbours, as the neighbours are turned a little on a curve
+		// so their directions do not fit the corners of the tile.
+		var to [2][3]float64
+		used := [2]bool{}
+		for axis, a := range frames[i].neighbours {
+			closest, along := 0, 0.0
+			for o, b := range frames[i].axes {
+				if d := dot(a, unit(b)); math.Abs(d) > math.Abs(along) {
+					closest, along = o, d
+				}
+			}
+
+			used[closest] = true
+			to[axis] = frames[i].axes[closest]
+			if along < 0 {
+				to[axis] = [3]float64{-to[axis][0], -to[axis][1], -to[axis][2]}
+			}
+		}
+
+		if used[0] && used[1] {
+			frames[i].neighbours = to
+		} else {
+			frames[i].neighbours = frames[i].axes
+		}
+	}
+
+	return frames
+}
+
+// flatCorner returns the corner of the flat layout that a corner of a tile should be on,
+// from the side of the tile centre it is on along each direction. The directions need not
+// be square to each other, as the tiles can be sheared.
+func (r tileRect) flatCorner(corner, centre [3]float64, axes [2][3]float64) (float64, float64) {
+
+	// solve corner - centre = a * right + b * up
+	p := sub(corner, centre)
+	rr, ru, uu := dot(axes[0], axes[0]), dot(axes[0], axes[1]), dot(axes[1], axes[1])
+	pr, pu := dot(p, axes[0]), dot(p, axes[1])
+	// only the signs are needed, and the determinant is never negative
+	a, b := pr*uu-pu*ru, pu*rr-pr*ru
+
+	x, y := r.x0, r.y1
+	if a > 0 {
+		x = r.x1
+	}
+	if b > 0 {
+		y = r.y0
+	}
+
+	return x, y
+}
+
+/*
+uncoveredRegions finds the regions of the canvas that are not covered by a tile.
+
+The canvas is split into cells at the edges of every tile, so each cell is either
+covered or not. The uncovered cells that touch are joined into regions. Regions
+that are no wider or taller than the tolerance, such as the rounding gaps
+between tiles, are not reported.
+*/
+func uncoveredRegions(canvas gridgen.XY2D, rects []tileRect, tolerance float64) ([]string, int) {
+
+	// the edges of the cells, inside the canvas
+	edges := func(lo, hi float64, ends func(r tileRect) (float64, float64)) []float64 {
+		set := map[float64]bool{lo: true, hi: true}
+		for _, r := range rects {
+			a, b := ends(r)
+			set[math.Max(lo, math.Min(hi, a))] = true
+			set[math.Max(lo, math.Min(hi, b))] = true
+		}
+
+		out := make([]float64, 0, len(set))
+		for e := range set {
+			out = append(out, e)
+		}
+		sort.Float64s(out)
+
+		return out
+	}
+
+	xs := edges(float64(canvas.X0), float64(canvas.X1), func(r tileRect) (float64, float64) { return r.x0, r.x1 })
+	ys := edges(float64(canvas.Y0), float64(canvas.Y1), func(r tileRect) (float64, float64) { return r.y0, r.y1 })
+	if len(xs) < 2 || len(ys) < 2 {
+		return nil, 0
+	}
+
+	cols, rows := len(xs)-1, len(ys)-1
+	covered := make([]bool, cols*rows)
+	for _, r := range rects {
+		c0, c1 := sort.SearchFloat64s(xs, r.x0), sort.SearchFloat64s(xs, r.x1)
+		r0, r1 := sort.SearchFloat64s(ys, r.y0), sort.SearchFloat64s(ys, r.y1)
+		for row := r0; row < min(r1, rows); row++ {
+			for col := c0; col < min(c1, cols); col++ {
+				covered[row*cols+col] = true
+			}
+		}
+	}
+
+	regions := []string{}
+	total := 0
+	seen := make([]bool, cols*rows)
+	for start := range covered {
+		if covered[start] || seen[start] {
+			continue
+		}
+
+		// join the touching uncovered cells
+		region := tileRect{x0: math.Inf(1), y0: math.Inf(1), x1: math.Inf(-1), y1: math.Inf(-1)}
+		area := 0.0
+		stack := []int{start}
+		seen[start] = true
+		for len(stack) > 0 {
+			cell := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			row, col := cell/cols, cell%cols
+			region.x0, region.x1 = math.Min(region.x0, xs[col]), math.Max(region.x1, xs[col+1])
+			region.y0, region.y1 = math.Min(region.y0, ys[row]), math.Max(region.y1, ys[row+1])
+			area += (xs[col+1] - xs[col]) * (ys[row+1] - ys[row])
+
+			for _, next := range [][2]int{{row - 1, col}, {row + 1, col}, {row, col - 1}, {row, col + 1}} {
+				if next[0] < 0 || next[0] >= rows || next[1] < 0 || next[1] >= cols {
+					continue
+				}
+
+				n := next[0]*cols + next[1]
+				if !covered[n] && !seen[n] {
+					seen[n] = true
+					stack = append(stack, n)
+				}
+			}
+		}
+
+		if region.x1-region.x0 <= tolerance || region.y1-region.y0 <= tolerance {
+			continue
+		}
+
+		total += int(area)
+		regions = append(regions, fmt.Sprintf("%s, %.0f pixels", region, area))
+	}
+
+	return regions, total
+}
+
+// String returns the region as x0,y0 - x1,y1
+func (r tileRect) String() string {
+	return fmt.Sprintf("%.6g,%.6g - %.6g,%.6g", r.x0, r.y0, r.x1, r.y1)
+}
+
+// canvasRect returns the region of a canvas
+func canvasRect(canvas gridgen.XY2D) tileRect {
+	return tileRect{x0: float64(canvas.X0), y0: float64(canvas.Y0), x1: float64(canvas.X1), y1: float64(canvas.Y1)}
+}
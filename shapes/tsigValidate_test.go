@@ -0,0 +1,82 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"testing"
+)
+
+func TestValidateModel(t *testing.T) {
+
+	for _, tc := range []struct {
+		name string
+		// change breaks the 3x2 wall
+		change                        func(m *Model)
+		mismatches, overlaps, outside int
+		uncovered, uncoveredPixels    int
+	}{
+		{name: "whole wall", change: func(m *Model) {}},
+		{name: "overlap", change: func(m *Model) {
+			// move r0c1 half over r0c0, with its uv map
+			m.Tiles[1].Flat.X -= 5
+			for c := range m.Tiles[1].UVs {
+				m.Tiles[1].UVs[c][0] -= 5.0 / 30
+			}
+		}, overlaps: 1, uncovered: 1, uncoveredPixels: 50},
+		{name: "overlap within the tolerance", change: func(m *Model) {
+			m.Tiles[1].Flat.X--
+			for c := range m.Tiles[1].UVs {
+				m.Tiles[1].UVs[c][0] -= 1.0 / 30
+			}
+		}},
+		{name: "outside", change: func(m *Model) {
+			// move r0c2 off the right of the canvas, with its uv map
+			m.Tiles[2].Flat.X += 10
+			for c := range m.Tiles[2].UVs {
+				m.Tiles[2].UVs[c][0] += 10.0 / 30
+			}
+		}, outside: 1, uncovered: 1, uncoveredPixels: 100},
+		{name: "mismatch", change: func(m *Model) {
+			// move r1c0 down onto r0c0, without its uv map
+			m.Tiles[3].Flat.Y += 3
+		}, mismatches: 1, overlaps: 1, uncovered: 1, uncoveredPixels: 30},
+		{name: "mirrored", change: func(m *Model) {
+			// swap the left and right of the uv map of r1c1
+			uvs := &m.Tiles[4].UVs
+			uvs[0], uvs[1], uvs[2], uvs[3] = uvs[1], uvs[0], uvs[3], uvs[2]
+		}, mismatches: 1},
+		{name: "turned", change: func(m *Model) {
+			// turn the uv map of r1c1 a quarter, the tiles are square so it covers the same pixels
+			uvs := &m.Tiles[4].UVs
+			uvs[0], uvs[1], uvs[2], uvs[3] = uvs[1], uvs[2], uvs[3], uvs[0]
+		}, mismatches: 1},
+		{name: "crossed", change: func(m *Model) {
+			// swap two corners of the uv map of r0c0, so it folds over itself
+			uvs := &m.Tiles[0].UVs
+			uvs[0], uvs[1] = uvs[1], uvs[0]
+		}, mismatches: 1},
+		{name: "uncovered", change: func(m *Model) {
+			// remove r1c1
+			m.Tiles = append(m.Tiles[:4], m.Tiles[5:]...)
+		}, uncovered: 1, uncoveredPixels: 100},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := testWall(t, 3, 2)
+			tc.change(m)
+
+			v := validateModel(m, 1)
+			if len(v.Mismatches) != tc.mismatches || len(v.Overlaps) != tc.overlaps || len(v.Outside) != tc.outside {
+				t.Errorf("got %v mismatches, %v overlaps and %v outside, want %v, %v and %v: %v %v %v",
+					len(v.Mismatches), len(v.Overlaps), len(v.Outside), tc.mismatches, tc.overlaps, tc.outside, v.Mismatches, v.Overlaps, v.Outside)
+			}
+
+			if len(v.Uncovered) != tc.uncovered || v.UncoveredPixels != tc.uncoveredPixels {
+				t.Errorf("got %v uncovered regions of %v pixels, want %v of %v pixels: %v",
+					len(v.Uncovered), v.UncoveredPixels, tc.uncovered, tc.uncoveredPixels, v.Uncovered)
+			}
+		})
+	}
+}
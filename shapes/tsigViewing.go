@@ -0,0 +1,341 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// add the command and its flags to the main handler
+func init() {
+	cmdViewing.Flags().StringVar(&objFile, "obj", "", "The obj file to analyse")
+	cmdViewing.Flags().StringVar(&tsigFile, "tsig", "", "The TSIG file of the obj")
+	cmdViewing.Flags().StringVar(&outFile, "outputFile", "./output", "The name of the csv and heat map pngs, each viewer after the first is numbered")
+	cmdViewing.Flags().StringArrayVar(&viewers, "viewer", nil, "The position of a viewer or camera as x,y,z, give the flag once for each viewer")
+	cmdViewing.Flags().StringVar(&heatMetric, "metric", MetricPixelsPerDegree, "The metric of the heat map, either ppd, incidence or distance")
+	cmdViewing.Flags().Float64Var(&heatScale, "scale", 1, "The scale of the heat map to the flat canvas")
+
+	cmdBoth.AddCommand(cmdViewing)
+}
+
+// viewing flags
+var (
+	viewers    []string
+	heatMetric = MetricPixelsPerDegree
+	heatScale  = 1.0
+)
+
+var cmdViewing = &cobra.Command{
+	Use:   "viewing",
+	Short: "analyse the viewing angle and pixel density of the tiles",
+	Long: `
+	Work out the viewing angle, distance and angular pixel density of
+	every tile of an obj, from each viewer or camera position. The tiles
+	are written as a csv, with a heat map png of the flat canvas for
+	each viewer.
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if len(viewers) == 0 {
+			return fmt.Errorf("no viewers were given, set their positions with --viewer x,y,z")
+		}
+
+		err := metricFence(heatMetric)
+		if err != nil {
+			return err
+		}
+
+		objBytes, err := os.ReadFile(objFile)
+		if err != nil {
+			return err
+		}
+
+		fTSIG, err := os.Open(tsigFile)
+		if err != nil {
+			return err
+		}
+		defer fTSIG.Close()
+
+		model, err := modelFromOutput(objFile, bytes.NewReader(objBytes), fTSIG)
+		if err != nil {
+			return err
+		}
+		model.Handedness = previewHandedness(objBytes)
+
+		views := make([][]tileView, len(viewers))
+		for i, v := range viewers {
+			pos, err := parseVector(v)
+			if err != nil {
+				return err
+			}
+			views[i] = viewTiles(model, pos)
+		}
+
+		fCSV, err := os.Create(outFile + ".csv")
+		if err != nil {
+			return err
+		}
+		defer fCSV.Close()
+
+		table := csv.NewWriter(fCSV)
+		table.Write([]string{"viewer", "tile", "distance", "incidence", "ppd", "ppd across", "ppd up"})
+
+		// the heat maps share a scale, so the viewers can be compared
+		lo, hi := metricRange(views, heatMetric)
+		for i, vs := range views {
+			facing := 0
+			for j, v := range vs {
+				if !v.behind() {
+					facing++
+				}
+
+				table.Write([]string{viewers[i], model.Tiles[j].Name, fmt.Sprintf("%.4f", v.Distance), fmt.Sprintf("%.2f", v.Incidence),
+					fmt.Sprintf("%.2f", v.ppd()), fmt.Sprintf("%.2f", v.PixelsPerDegree[0]), fmt.Sprintf("%.2f", v.PixelsPerDegree[1])})
+			}
+
+			img, err := heatMap(model, vs, heatMetric, lo, hi, heatScale)
+			if err != nil {
+				return err
+			}
+
+			name := outFile + ".png"
+			if i > 0 {
+				name = fmt.Sprintf("%s-%v.png", outFile, i+1)
+			}
+
+			fPNG, err := os.Create(name)
+			if err != nil {
+				return err
+			}
+
+			err = png.Encode(fPNG, img)
+			fPNG.Close()
+			if err != nil {
+				return err
+			}
+
+			vlo, vhi := metricRange([][]tileView{vs}, heatMetric)
+			fmt.Printf("viewer %s: %v of %v tiles face the viewer, %s from %.4g to %.4g\n", viewers[i], facing, len(vs), heatMetric, vlo, vhi)
+		}
+
+		table.Flush()
+
+		return table.Error()
+	},
+}
+
+const (
+	// MetricPixelsPerDegree colours the heat map by the angular pixel density
+	MetricPixelsPerDegree = "ppd"
+	// MetricIncidence colours the heat map by the viewing angle of the tiles
+	MetricIncidence = "incidence"
+	// MetricDistance colours the heat map by the distance to the tiles
+	MetricDistance = "distance"
+)
+
+// the colour of the heat map where there is no tile
+var heatBackground = color.RGBA{R: 25, G: 25, B: 25, A: 255}
+
+// the colour of the tiles that face away from the viewer
+var heatBehind = color.RGBA{R: 90, G: 90, B: 90, A: 255}
+
+// tileView is how a tile is seen from a viewer.
+type tileView struct {
+	// Distance from the viewer to the centre of the tile, in the units of the obj
+	Distance float64
+	// Incidence is the angle in degrees between the front of the tile and the
+	// viewer, 0 is head on and over 90 is behind the tile. The front of a tile
+	// is the side its test pattern reads the right way round from.
+	Incidence float64
+	// PixelsPerDegree is the angular pixel density across and up the tile,
+	// from the pixels of the tile and the angle it fills.
+	PixelsPerDegree [2]float64
+}
+
+// behind reports if the viewer sees the back of the tile
+func (v tileView) behind() bool {
+	return v.Incidence > 90
+}
+
+// ppd returns the lowest angular pixel density of the tile,
+// which is the density the viewer can resolve.
+func (v tileView) ppd() float64 {
+	return math.Min(v.PixelsPerDegree[0], v.PixelsPerDegree[1])
+}
+
+// metric returns the value of the tile for the metric, and if higher is better
+func (v tileView) metric(metric string) (float64, bool) {
+	switch metric {
+	case MetricIncidence:
+		return v.Incidence, false
+	case MetricDistance:
+		return v.Distance, false
+	default:
+		return v.ppd(), true
+	}
+}
+
+// metricFence checks the metric of the heat map is known.
+func metricFence(metric string) error {
+	switch metric {
+	case MetricPixelsPerDegree, MetricIncidence, MetricDistance:
+		return nil
+	default:
+		return fmt.Errorf("unknown metric %q, the metric must be %q, %q or %q", metric, MetricPixelsPerDegree, MetricIncidence, MetricDistance)
+	}
+}
+
+/*
+viewTiles works out how each tile of the model is seen from the viewer.
+
+The pixel density across a tile is the pixels across the tile, over the angle
+between the middle of its left and right edges as seen by the viewer, and the
+same for up the tile. The left, right, bottom and top of each tile are found
+from its uv map, so they match the pixels of its flat layout.
+
+The front of a tile is found from its uv map as well, rather than the winding
+of its face, as shapes such as curves keep a layout that is viewed from the
+side their faces point away from. Left handed models are mirrored,
+so their front is on the other side of the uv map.
+*/
+func viewTiles(m *Model, viewer [3]float64) []tileView {
+
+	views := make([]tileView, len(m.Tiles))
+	for i, t := range m.Tiles {
+
+		var centre [3]float64
+		for _, p := range t.Corners {
+			for k := range centre {
+				centre[k] += p[k] / 4
+			}
+		}
+
+		left, right := edgeMiddles(t, 0)
+		bottom, top := edgeMiddles(t, 1)
+		// the pattern reads left to right and bottom to top from the front
+		front := unit(cross(sub(right, left), sub(top, bottom)))
+		if m.Handedness == HandednessLeft {
+			front = [3]float64{-front[0], -front[1], -front[2]}
+		}
+
+		toViewer := sub(viewer, centre)
+		v := tileView{Distance: math.Sqrt(dot(toViewer, toViewer))}
+		v.Incidence = math.Acos(math.Max(-1, math.Min(1, dot(front, unit(toViewer))))) * 180 / math.Pi
+
+		for axis, pixels := range []int{t.Size.X, t.Size.Y} {
+			lo, hi := left, right
+			if axis == 1 {
+				lo, hi = bottom, top
+			}
+			angle := math.Acos(math.Max(-1, math.Min(1, dot(unit(sub(lo, viewer)), unit(sub(hi, viewer)))))) * 180 / math.Pi
+			if angle > 0 {
+				v.PixelsPerDegree[axis] = float64(pixels) / angle
+			}
+		}
+
+		views[i] = v
+	}
+
+	return views
+}
+
+// edgeMiddles returns the middle of the two edges of a tile at the lowest
+// and highest of the uv map along the axis, 0 for u and 1 for v.
+func edgeMiddles(t ModelTile, axis int) ([3]float64, [3]float64) {
+
+	order := []int{0, 1, 2, 3}
+	for a := 1; a < len(order); a++ {
+		for b := a; b > 0 && t.UVs[order[b]][axis] < t.UVs[order[b-1]][axis]; b-- {
+			order[b], order[b-1] = order[b-1], order[b]
+		}
+	}
+
+	mid := func(a, b int) [3]float64 {
+		p, q := t.Corners[a], t.Corners[b]
+		return [3]float64{(p[0] + q[0]) / 2, (p[1] + q[1]) / 2, (p[2] + q[2]) / 2}
+	}
+
+	return mid(order[0], order[1]), mid(order[2], order[3])
+}
+
+// metricRange returns the lowest and highest value of the metric, of
+// the tiles that face the viewers. It is 0, 0 if no tiles face a viewer.
+func metricRange(views [][]tileView, metric string) (float64, float64) {
+
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, vs := range views {
+		for _, v := range vs {
+			if v.behind() {
+				continue
+			}
+			value, _ := v.metric(metric)
+			lo, hi = math.Min(lo, value), math.Max(hi, value)
+		}
+	}
+
+	if lo > hi {
+		return 0, 0
+	}
+
+	return lo, hi
+}
+
+/*
+heatMap draws the flat canvas of the model, with each tile coloured by the
+metric of how it is seen, from red for the worst through yellow to green for
+the best, between lo and hi. Tiles that face away from the viewer are grey.
+The canvas is scaled by scale.
+*/
+func heatMap(m *Model, views []tileView, metric string, lo, hi, scale float64) (*image.RGBA, error) {
+
+	if scale <= 0 {
+		return nil, fmt.Errorf("the scale must be greater than 0, got %v", scale)
+	}
+
+	width := int(math.Round(float64(m.Flat.X1-m.Flat.X0) * scale))
+	height := int(math.Round(float64(m.Flat.Y1-m.Flat.Y0) * scale))
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("the flat canvas of %vx%v pixels is empty at a scale of %v", m.Flat.X1-m.Flat.X0, m.Flat.Y1-m.Flat.Y0, scale)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: heatBackground}, image.Point{}, draw.Src)
+
+	for i, t := range m.Tiles {
+		c := heatBehind
+		if !views[i].behind() {
+			value, higherBetter := views[i].metric(metric)
+			f := 1.0
+			if hi > lo {
+				f = (value - lo) / (hi - lo)
+			}
+			if !higherBetter {
+				f = 1 - f
+			}
+
+			// red to yellow to green, as the hue goes from 0 to a third
+			r, g, b := hsv(f/3, 0.8, 0.9)
+			c = color.RGBA{R: uint8(r * 255), G: uint8(g * 255), B: uint8(b * 255), A: 255}
+		}
+
+		rect := image.Rect(
+			int(math.Round(float64(t.Flat.X-m.Flat.X0)*scale)), int(math.Round(float64(t.Flat.Y-m.Flat.Y0)*scale)),
+			int(math.Round(float64(t.Flat.X+t.Size.X-m.Flat.X0)*scale)), int(math.Round(float64(t.Flat.Y+t.Size.Y-m.Flat.Y0)*scale)))
+		draw.Draw(img, rect, &image.Uniform{C: c}, image.Point{}, draw.Src)
+	}
+
+	return img, nil
+}
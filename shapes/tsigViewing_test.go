@@ -0,0 +1,60 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"math"
+	"testing"
+)
+
+func TestViewTiles(t *testing.T) {
+
+	// the angle in degrees of 1 unit seen head on from a distance of 2
+	across := 2 * math.Atan(0.25) * 180 / math.Pi
+
+	for _, tc := range []struct {
+		name   string
+		viewer [3]float64
+		left   bool
+		// the view of the tile, and if it is seen from behind
+		distance, incidence float64
+		ppd                 [2]float64
+		behind              bool
+	}{
+		{name: "head on", viewer: [3]float64{0.5, -2, 0.5},
+			distance: 2, incidence: 0, ppd: [2]float64{100 / across, 50 / across}},
+		{name: "from the side", viewer: [3]float64{2.5, -2, 0.5},
+			distance: math.Sqrt(8), incidence: 45},
+		{name: "along the wall", viewer: [3]float64{3, 0, 0.5},
+			distance: 2.5, incidence: 90},
+		{name: "from behind", viewer: [3]float64{0.5, 2, 0.5},
+			distance: 2, incidence: 180, ppd: [2]float64{100 / across, 50 / across}, behind: true},
+		{name: "left handed from the front", viewer: [3]float64{0.5, 2, 0.5}, left: true,
+			distance: 2, incidence: 0, ppd: [2]float64{100 / across, 50 / across}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// a single tile of 100x50 pixels, facing along -y
+			m, err := FlatWall{TileWidth: 1, TileHeight: 1, WallWidth: 1, WallHeight: 1, Dx: 100, Dy: 50}.Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tc.left {
+				m.Handedness = HandednessLeft
+			}
+
+			v := viewTiles(m, tc.viewer)[0]
+			if math.Abs(v.Distance-tc.distance) > 1e-9 || math.Abs(v.Incidence-tc.incidence) > 1e-6 || v.behind() != tc.behind {
+				t.Errorf("the tile is %v away at %v degrees, behind is %v, want %v at %v degrees and %v",
+					v.Distance, v.Incidence, v.behind(), tc.distance, tc.incidence, tc.behind)
+			}
+
+			if tc.ppd != [2]float64{} && (math.Abs(v.PixelsPerDegree[0]-tc.ppd[0]) > 1e-9 || math.Abs(v.PixelsPerDegree[1]-tc.ppd[1]) > 1e-9) {
+				t.Errorf("the tile has %v pixels per degree, want %v", v.PixelsPerDegree, tc.ppd)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"sort"
+)
+
+// the corners a wiring chain can start from
+const (
+	CornerTopLeft     = "topLeft"
+	CornerTopRight    = "topRight"
+	CornerBottomLeft  = "bottomLeft"
+	CornerBottomRight = "bottomRight"
+)
+
+// the directions a wiring chain snakes in
+const (
+	SnakeHorizontal = "horizontal"
+	SnakeVertical   = "vertical"
+)
+
+// WiringConfig is the wiring block of a configuration, it describes
+// how the tiles are wired in serpentine data chains.
+type WiringConfig struct {
+	// StartCorner is the corner of each face the first chain starts from.
+	// Defaults to topLeft.
+	StartCorner string `json:"startCorner" yaml:"startCorner"`
+	// Direction is "horizontal" to snake along the rows of tiles,
+	// or "vertical" to snake along the columns. Defaults to horizontal.
+	Direction string `json:"direction" yaml:"direction"`
+	// MaxTilesPerChain is the most tiles a single port can drive
+	MaxTilesPerChain int `json:"maxTilesPerChain" yaml:"maxTilesPerChain"`
+	// PortsPerReceiver is the number of ports on each receiver, if it is 0
+	// there is a single receiver and each chain has its own port.
+	PortsPerReceiver int `json:"portsPerReceiver" yaml:"portsPerReceiver"`
+}
+
+// wiringChain is a chain of physical tiles driven from one port
+type wiringChain struct {
+	// the chain, receiver and port numbers, counting from 1
+	chain, receiver, port int
+	tiles                 []carveUnit
+}
+
+/*
+planWiring wires the tiles of the model in serpentine chains, and tags every
+tile with its chain, port and index in the chain, e.g. chain:2, port:2 and index:7.
+The receiver is tagged as well if the ports per receiver are given.
+
+Each face of the model is wired on its own, using the rows and columns of the tiles.
+The snake starts from the start corner of the face, runs along the first row
+(or column), then back along the next, until the face is finished. A new chain is
+started every time a chain reaches the max tiles per chain, and for every face.
+The strips of a tile are wired as the one tile.
+*/
+func planWiring(m *Model, c WiringConfig) ([]wiringChain, error) {
+
+	corner := c.StartCorner
+	if corner == "" {
+		corner = CornerTopLeft
+	}
+
+	direction := c.Direction
+	if direction == "" {
+		direction = SnakeHorizontal
+	}
+
+	switch corner {
+	case CornerTopLeft, CornerTopRight, CornerBottomLeft, CornerBottomRight:
+	default:
+		return nil, fmt.Errorf("unknown wiring start corner %q, the start corner must be %q, %q, %q or %q", c.StartCorner, CornerTopLeft, CornerTopRight, CornerBottomLeft, CornerBottomRight)
+	}
+
+	if direction != SnakeHorizontal && direction != SnakeVertical {
+		return nil, fmt.Errorf("unknown wiring direction %q, the direction must be %q or %q", c.Direction, SnakeHorizontal, SnakeVertical)
+	}
+
+	if c.MaxTilesPerChain <= 0 {
+		return nil, fmt.Errorf("the max tiles per chain must be greater than 0, got %v", c.MaxTilesPerChain)
+	}
+
+	if c.PortsPerReceiver < 0 {
+		return nil, fmt.Errorf("the ports per receiver can not be negative, got %v", c.PortsPerReceiver)
+	}
+
+	// rows are counted from the bottom and columns from the left
+	fromTop := corner == CornerTopLeft || corner == CornerTopRight
+	fromLeft := corner == CornerTopLeft || corner == CornerBottomLeft
+
+	// group the tiles by face, keeping the order of the faces
+	faces := []string{}
+	byFace := map[string][]carveUnit{}
+	for _, u := range carveUnits(m.Tiles) {
+		face := m.Tiles[u.tiles[0]].Face
+		if _, ok := byFace[face]; !ok {
+			faces = append(faces, face)
+		}
+		byFace[face] = append(byFace[face], u)
+	}
+
+	chains := []wiringChain{}
+	for _, face := range faces {
+		units := byFace[face]
+
+		// line is the row or column the snake runs along,
+		// and step is the position along the line
+		line := func(u carveUnit) int {
+			t := m.Tiles[u.tiles[0]]
+			if direction == SnakeHorizontal {
+				if fromTop {
+					return -t.Row
+				}
+				return t.Row
+			}
+
+			if fromLeft {
+				return t.Col
+			}
+			return -t.Col
+		}
+
+		step := func(u carveUnit) int {
+			t := m.Tiles[u.tiles[0]]
+			if direction == SnakeHorizontal {
+				if fromLeft {
+					return t.Col
+				}
+				return -t.Col
+			}
+
+			if fromTop {
+				return -t.Row
+			}
+			return t.Row
+		}
+
+		sort.SliceStable(units, func(i, j int) bool {
+			if line(units[i]) != line(units[j]) {
+				return line(units[i]) < line(units[j])
+			}
+			return step(units[i]) < step(units[j])
+		})
+
+		// reverse every other line to make the snake
+		for start, count := 0, 0; start < len(units); count++ {
+			end := start
+			for end < len(units) && line(units[end]) == line(units[start]) {
+				end++
+			}
+
+			if count%2 == 1 {
+				for i, j := start, end-1; i < j; i, j = i+1, j-1 {
+					units[i], units[j] = units[j], units[i]
+				}
+			}
+			start = end
+		}
+
+		for start := 0; start < len(units); start += c.MaxTilesPerChain {
+			end := min(start+c.MaxTilesPerChain, len(units))
+			chains = append(chains, wiringChain{chain: len(chains) + 1, tiles: units[start:end]})
+		}
+	}
+
+	for i := range chains {
+		ch := &chains[i]
+		ch.receiver, ch.port = 1, ch.chain
+		if c.PortsPerReceiver > 0 {
+			ch.receiver = (ch.chain-1)/c.PortsPerReceiver + 1
+			ch.port = (ch.chain-1)%c.PortsPerReceiver + 1
+		}
+
+		for index, u := range ch.tiles {
+			tags := []string{fmt.Sprintf("chain:%v", ch.chain), fmt.Sprintf("port:%v", ch.port), fmt.Sprintf("index:%v", index+1)}
+			if c.PortsPerReceiver > 0 {
+				tags = append(tags, fmt.Sprintf("receiver:%v", ch.receiver))
+			}
+
+			for _, t := range u.tiles {
+				m.Tiles[t].Tags = append(m.Tiles[t].Tags, tags...)
+			}
+		}
+	}
+
+	return chains, nil
+}
+
+// the colours of the chains in the wiring diagram
+var wiringColours = []string{"#e6194b", "#3cb44b", "#4363d8", "#f58231", "#911eb4", "#42d4f4", "#f032e6", "#9a6324"}
+
+/*
+writeWiringSVG writes the wiring diagram of the chains, drawn on the flat layout of the model.
+
+Every tile is drawn with its chain and index, and arrows follow each chain from
+tile to tile. The first tile of a chain is marked with a circle and its port.
+*/
+func writeWiringSVG(w io.Writer, m *Model, chains []wiringChain) error {
+
+	width, height := m.Flat.X1-m.Flat.X0, m.Flat.Y1-m.Flat.Y0
+
+	// the size of the text and lines follows the smallest tile
+	size := math.Inf(1)
+	for _, ch := range chains {
+		for _, u := range ch.tiles {
+			size = math.Min(size, float64(min(u.bounds.X1-u.bounds.X0, u.bounds.Y1-u.bounds.Y0)))
+		}
+	}
+	if math.IsInf(size, 1) {
+		size = 100
+	}
+
+	var svg bytes.Buffer
+	fmt.Fprintf(&svg, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%v\" height=\"%v\" viewBox=\"%v %v %v %v\">\n", width, height, m.Flat.X0, m.Flat.Y0, width, height)
+	svg.WriteString("<defs>\n")
+	for i, colour := range wiringColours {
+		fmt.Fprintf(&svg, "<marker id=\"arrow%v\" viewBox=\"0 0 10 10\" refX=\"10\" refY=\"5\" markerWidth=\"4\" markerHeight=\"4\" orient=\"auto\"><path d=\"M0,0 L10,5 L0,10 z\" fill=\"%s\"/></marker>\n", i, colour)
+	}
+	svg.WriteString("</defs>\n")
+	fmt.Fprintf(&svg, "<rect x=\"%v\" y=\"%v\" width=\"%v\" height=\"%v\" fill=\"white\"/>\n", m.Flat.X0, m.Flat.Y0, width, height)
+
+	centre := func(u carveUnit) (float64, float64) {
+		return float64(u.bounds.X0+u.bounds.X1) / 2, float64(u.bounds.Y0+u.bounds.Y1) / 2
+	}
+
+	for _, ch := range chains {
+		c := (ch.chain - 1) % len(wiringColours)
+		colour := wiringColours[c]
+
+		fmt.Fprintf(&svg, "<g id=\"chain%v\">\n", ch.chain)
+		for i, u := range ch.tiles {
+			x, y := centre(u)
+			fmt.Fprintf(&svg, "<rect x=\"%v\" y=\"%v\" width=\"%v\" height=\"%v\" fill=\"%s\" fill-opacity=\"0.15\" stroke=\"black\" stroke-width=\"%.3g\"><title>%s</title></rect>\n",
+				u.bounds.X0, u.bounds.Y0, u.bounds.X1-u.bounds.X0, u.bounds.Y1-u.bounds.Y0, colour, size/100, html.EscapeString(u.name))
+			fmt.Fprintf(&svg, "<text x=\"%.6g\" y=\"%.6g\" font-family=\"sans-serif\" font-size=\"%.3g\" text-anchor=\"middle\" dominant-baseline=\"middle\">%v.%v</text>\n",
+				x, y, size/5, ch.chain, i+1)
+
+			if i == 0 {
+				fmt.Fprintf(&svg, "<circle cx=\"%.6g\" cy=\"%.6g\" r=\"%.3g\" fill=\"none\" stroke=\"%s\" stroke-width=\"%.3g\"/>\n", x, y, size/3, colour, size/30)
+				fmt.Fprintf(&svg, "<text x=\"%.6g\" y=\"%.6g\" font-family=\"sans-serif\" font-size=\"%.3g\" text-anchor=\"middle\" fill=\"%s\">R%v P%v</text>\n",
+					x, y-size/3-size/20, size/8, colour, ch.receiver, ch.port)
+				continue
+			}
+
+			// the arrow from the last tile, trimmed so it
+			// does not cover the text of either tile
+			px, py := centre(ch.tiles[i-1])
+			dx, dy := x-px, y-py
+			length := math.Hypot(dx, dy)
+			if length == 0 {
+				continue
+			}
+			trim := math.Min(size/4, length/3)
+			fmt.Fprintf(&svg, "<line x1=\"%.6g\" y1=\"%.6g\" x2=\"%.6g\" y2=\"%.6g\" stroke=\"%s\" stroke-width=\"%.3g\" marker-end=\"url(#arrow%v)\"/>\n",
+				px+dx*trim/length, py+dy*trim/length, x-dx*trim/length, y-dy*trim/length, colour, size/30, c)
+		}
+		svg.WriteString("</g>\n")
+	}
+	svg.WriteString("</svg>\n")
+
+	if _, err := w.Write(svg.Bytes()); err != nil {
+		return fmt.Errorf("error writing to svg %v", err)
+	}
+
+	return nil
+}
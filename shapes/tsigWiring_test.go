@@ -0,0 +1,76 @@
+//	Copyright ©2019-2024  Mr MXF   info@mrmxf.com
+//	BSD-3-Clause License           https://opensource.org/license/bsd-3-clause/
+//
+// Package shapes contains the obj shapes and their configurations
+
+package shapes
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestPlanWiring(t *testing.T) {
+
+	for _, tc := range []struct {
+		name   string
+		config WiringConfig
+		// chains are the tiles of each chain of the 3x2 wall, in order
+		chains [][]string
+		err    bool
+	}{
+		{name: "from the top left",
+			config: WiringConfig{MaxTilesPerChain: 10},
+			chains: [][]string{{"flatwall/r1c0", "flatwall/r1c1", "flatwall/r1c2", "flatwall/r0c2", "flatwall/r0c1", "flatwall/r0c0"}}},
+		{name: "up and down from the bottom right",
+			config: WiringConfig{StartCorner: CornerBottomRight, Direction: SnakeVertical, MaxTilesPerChain: 10},
+			chains: [][]string{{"flatwall/r0c2", "flatwall/r1c2", "flatwall/r1c1", "flatwall/r0c1", "flatwall/r0c0", "flatwall/r1c0"}}},
+		{name: "split chains",
+			config: WiringConfig{StartCorner: CornerBottomLeft, MaxTilesPerChain: 4},
+			chains: [][]string{{"flatwall/r0c0", "flatwall/r0c1", "flatwall/r0c2", "flatwall/r1c2"}, {"flatwall/r1c1", "flatwall/r1c0"}}},
+		{name: "unknown corner",
+			config: WiringConfig{StartCorner: "middle", MaxTilesPerChain: 4},
+			err:    true},
+		{name: "no tiles per chain",
+			config: WiringConfig{},
+			err:    true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := testWall(t, 3, 2)
+
+			chains, err := planWiring(m, tc.config)
+			if tc.err {
+				if err == nil {
+					t.Fatal("got no error, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := [][]string{}
+			for _, ch := range chains {
+				names := []string{}
+				for _, u := range ch.tiles {
+					names = append(names, u.name)
+				}
+				got = append(got, names)
+			}
+
+			if !reflect.DeepEqual(got, tc.chains) {
+				t.Errorf("got the chains %v, want %v", got, tc.chains)
+			}
+
+			// the first tile of each chain is tagged as its start
+			for i, names := range tc.chains {
+				for _, tile := range m.Tiles {
+					if tile.Name == names[0] && !slices.Contains(tile.Tags, "index:1") {
+						t.Errorf("chain %v starts at %s, which is tagged %v", i+1, tile.Name, tile.Tags)
+					}
+				}
+			}
+		})
+	}
+}